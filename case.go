@@ -3,20 +3,15 @@ package squirrelly
 import (
 	"bytes"
 	"errors"
-
-	"github.com/lann/builder"
 )
 
-func init() {
-	builder.Register(CaseBuilder{}, caseData{})
-}
-
 // sqlizerBuffer is a helper that allows to write many Sqlizers one by one
 // without constant checks for errors that may come from Sqlizer
 type sqlizerBuffer struct {
 	bytes.Buffer
-	args []interface{}
-	err  error
+	dialect Dialect
+	args    []interface{}
+	err     error
 }
 
 // WriteSql converts Sqlizer to SQL strings and writes it to buffer
@@ -27,7 +22,7 @@ func (b *sqlizerBuffer) WriteSql(item Sqlizer) {
 
 	var str string
 	var args []interface{}
-	str, args, b.err = nestedToSql(item)
+	str, args, b.err = nestedToSql(item, b.dialect)
 
 	if b.err != nil {
 		return
@@ -60,14 +55,18 @@ type caseData struct {
 }
 
 // ToSql implements Sqlizer
-func (d *caseData) ToSql() (sqlStr string, args []interface{}, err error) {
+func (d *caseData) ToSql() (string, []interface{}, error) {
+	return d.toSqlDialect(nil)
+}
+
+func (d *caseData) toSqlDialect(dialect Dialect) (sqlStr string, args []interface{}, err error) {
 	if len(d.WhenParts) == 0 {
 		err = errors.New("case expression must contain at lease one WHEN clause")
 
 		return
 	}
 
-	sql := sqlizerBuffer{}
+	sql := sqlizerBuffer{dialect: dialect}
 
 	sql.WriteString("CASE ")
 	if d.What != nil {
@@ -92,12 +91,17 @@ func (d *caseData) ToSql() (sqlStr string, args []interface{}, err error) {
 }
 
 // CaseBuilder builds SQL CASE construct which could be used as parts of queries.
-type CaseBuilder builder.Builder
+type CaseBuilder struct {
+	data caseData
+}
 
 // ToSql builds the query into a SQL string and bound args.
 func (b CaseBuilder) ToSql() (string, []interface{}, error) {
-	data := builder.GetStruct(b).(caseData)
-	return data.ToSql()
+	return b.data.ToSql()
+}
+
+func (b CaseBuilder) toSqlDialect(d Dialect) (string, []interface{}, error) {
+	return b.data.toSqlDialect(d)
 }
 
 // MustSql builds the query into a SQL string and bound args.
@@ -112,17 +116,20 @@ func (b CaseBuilder) MustSql() (string, []interface{}) {
 
 // what sets optional value for CASE construct "CASE [value] ..."
 func (b CaseBuilder) what(expr interface{}) CaseBuilder {
-	return builder.Set(b, "What", newPart(expr)).(CaseBuilder)
+	b.data.What = newPart(expr)
+	return b
 }
 
 // When adds "WHEN ... THEN ..." part to CASE construct
 func (b CaseBuilder) When(when interface{}, then interface{}) CaseBuilder {
 	// TODO: performance hint: replace slice of WhenPart with just slice of parts
 	// where even indices of the slice belong to "when"s and odd indices belong to "then"s
-	return builder.Append(b, "WhenParts", newWhenPart(when, then)).(CaseBuilder)
+	b.data.WhenParts = appendCopy(b.data.WhenParts, newWhenPart(when, then))
+	return b
 }
 
 // What sets optional "ELSE ..." part for CASE construct
 func (b CaseBuilder) Else(expr interface{}) CaseBuilder {
-	return builder.Set(b, "Else", newPart(expr)).(CaseBuilder)
+	b.data.Else = newPart(expr)
+	return b
 }
@@ -0,0 +1,310 @@
+package squirrelly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+type compoundData struct {
+	PlaceholderFormat PlaceholderFormat
+	Dialect           Dialect
+	Prefixes          []Sqlizer
+	Selects           []compoundPart
+	LastCombined      int
+	OrderByParts      []Sqlizer
+	Limit             string
+	Offset            string
+	Suffixes          []Sqlizer
+}
+
+// compoundPart is a query combined into a compound select by its set operator,
+// which is empty for the first query.
+type compoundPart struct {
+	operator string
+	all      bool
+	query    Subquery
+}
+
+func (d *compoundData) ToSql() (sqlStr string, args []interface{}, err error) {
+	sqlStr, args, err = d.toSqlRaw()
+	if err != nil {
+		return
+	}
+
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, d.Dialect, sqlStr)
+	return
+}
+
+func (d *compoundData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
+	if len(d.Selects) < 2 {
+		err = errors.New("compound select statements must combine at least two queries")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	if len(d.Prefixes) > 0 {
+		args, err = appendToSql(d.Prefixes, d.Dialect, sql, " ", args)
+		if err != nil {
+			return
+		}
+
+		sql.WriteString(" ")
+	}
+
+	dialect := dialectOrDefault(d.Dialect)
+	parens := dialect.CompoundParens()
+
+	selects := &bytes.Buffer{}
+	for idx, part := range d.Selects {
+		if parens && idx > 1 && compoundPrecedence(part.operator) > compoundPrecedence(d.Selects[idx-1].operator) {
+			// queries are combined from left to right, so the queries before
+			// an operator that binds more tightly than the previous one are
+			// parenthesised
+			combined := selects.String()
+			selects.Reset()
+			fmt.Fprintf(selects, "(%s)", combined)
+		}
+
+		if idx > 0 {
+			selects.WriteString(" ")
+			selects.WriteString(part.operator)
+			if part.all {
+				selects.WriteString(" ALL")
+			}
+			selects.WriteString(" ")
+		}
+
+		needsParens := compoundNeedsParens(part.query)
+		if needsParens && !parens {
+			err = fmt.Errorf("%s does not support parenthesised queries in compound selects, which are needed to combine queries with ORDER BY, LIMIT, OFFSET or WITH clauses, or nested compound selects", dialect.Name())
+			return
+		}
+
+		var partSql string
+		var partArgs []interface{}
+		partSql, partArgs, err = nestedToSql(part.query, d.Dialect)
+		if err != nil {
+			return
+		}
+
+		if needsParens {
+			fmt.Fprintf(selects, "(%s)", partSql)
+		} else {
+			selects.WriteString(partSql)
+		}
+		args = append(args, partArgs...)
+	}
+	sql.Write(selects.Bytes())
+
+	if len(d.OrderByParts) > 0 {
+		sql.WriteString(" ORDER BY ")
+		args, err = appendToSql(d.OrderByParts, d.Dialect, sql, ", ", args)
+		if err != nil {
+			return
+		}
+	}
+
+	if limitOffset := dialectOrDefault(d.Dialect).LimitOffset(d.Limit, d.Offset, len(d.OrderByParts) > 0); limitOffset != "" {
+		sql.WriteString(" ")
+		sql.WriteString(limitOffset)
+	}
+
+	if len(d.Suffixes) > 0 {
+		sql.WriteString(" ")
+
+		args, err = appendToSql(d.Suffixes, d.Dialect, sql, " ", args)
+		if err != nil {
+			return
+		}
+	}
+
+	sqlStr = sql.String()
+	return
+}
+
+// compoundPrecedence returns the precedence of a set operator. INTERSECT binds
+// more tightly than UNION and EXCEPT.
+func compoundPrecedence(operator string) int {
+	if operator == "INTERSECT" {
+		return 2
+	}
+	return 1
+}
+
+// compoundNeedsParens reports whether query must be parenthesised to be combined
+// into a compound select, because it's compound itself or has clauses that
+// would otherwise apply to the whole compound select.
+func compoundNeedsParens(query Subquery) bool {
+	switch q := query.(type) {
+	case CompoundBuilder:
+		return true
+	case SelectBuilder:
+		return len(q.data.OrderByParts) > 0 || q.data.Limit != "" || q.data.Offset != "" || len(q.data.With) > 0
+	}
+	return false
+}
+
+// Builder
+
+// CompoundBuilder builds compound SQL SELECT statements, which combine queries
+// with UNION, INTERSECT or EXCEPT.
+//
+// Queries are combined from left to right, so a.Union(b).Intersect(c) matches
+// the rows of a or b that are also in c, and renders as
+// (a UNION b) INTERSECT c.
+//
+// Queries with an ORDER BY, LIMIT or OFFSET clause, nested compound selects,
+// and chains like the one above, are parenthesised. sqlite doesn't accept
+// parentheses, but applies every operator from left to right anyway, so its
+// chains are rendered without them, and combining queries that need them is
+// an error. ORDER BY, LIMIT and OFFSET clauses set on the CompoundBuilder
+// apply to the combined rows:
+//
+//	Union(
+//		Select("id", "name").From("users"),
+//		Select("id", "name").From("archived_users"),
+//	).All().OrderBy("name").Limit(10)
+type CompoundBuilder struct {
+	data compoundData
+}
+
+func newCompoundBuilder(format PlaceholderFormat, dialect Dialect, operator string, all bool, queries []Subquery) CompoundBuilder {
+	b := CompoundBuilder{data: compoundData{PlaceholderFormat: format, Dialect: dialect}}
+	return b.combine(operator, all, queries...)
+}
+
+// Format methods
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b CompoundBuilder) PlaceholderFormat(f PlaceholderFormat) CompoundBuilder {
+	b.data.PlaceholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect used to render the query, along with its
+// PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b CompoundBuilder) Dialect(d Dialect) CompoundBuilder {
+	b.data.Dialect = d
+	b.data.PlaceholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// SQL methods
+
+// ToSql builds the query into a SQL string and bound args.
+func (b CompoundBuilder) ToSql() (string, []interface{}, error) {
+	return b.data.ToSql()
+}
+
+func (b CompoundBuilder) toSqlRaw(d Dialect) (string, []interface{}, error) {
+	if b.data.Dialect == nil {
+		b.data.Dialect = d
+	}
+	return b.data.toSqlRaw()
+}
+
+func (b CompoundBuilder) debugFormat() (PlaceholderFormat, Dialect) {
+	return b.data.PlaceholderFormat, b.data.Dialect
+}
+
+// MustSql builds the query into a SQL string and bound args.
+// It panics if there are any errors.
+func (b CompoundBuilder) MustSql() (string, []interface{}) {
+	sql, args, err := b.ToSql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Prefix adds an expression to the beginning of the query
+func (b CompoundBuilder) Prefix(sql string, args ...interface{}) CompoundBuilder {
+	return b.PrefixExpr(Expr(sql, args...))
+}
+
+// PrefixExpr adds an expression to the very beginning of the query
+func (b CompoundBuilder) PrefixExpr(expr Sqlizer) CompoundBuilder {
+	b.data.Prefixes = appendCopy(b.data.Prefixes, expr)
+	return b
+}
+
+// Union combines query into the compound select with UNION.
+func (b CompoundBuilder) Union(query Subquery) CompoundBuilder {
+	return b.combine("UNION", false, query)
+}
+
+// UnionAll combines query into the compound select with UNION ALL.
+func (b CompoundBuilder) UnionAll(query Subquery) CompoundBuilder {
+	return b.combine("UNION", true, query)
+}
+
+// Intersect combines query into the compound select with INTERSECT.
+func (b CompoundBuilder) Intersect(query Subquery) CompoundBuilder {
+	return b.combine("INTERSECT", false, query)
+}
+
+// Except combines query into the compound select with EXCEPT.
+func (b CompoundBuilder) Except(query Subquery) CompoundBuilder {
+	return b.combine("EXCEPT", false, query)
+}
+
+func (b CompoundBuilder) combine(operator string, all bool, queries ...Subquery) CompoundBuilder {
+	b.data.LastCombined = len(b.data.Selects)
+	for _, query := range queries {
+		b.data.Selects = appendCopy(b.data.Selects, compoundPart{operator: operator, all: all, query: query})
+	}
+	return b
+}
+
+// All keeps duplicate rows, adding ALL to the set operators that combined the
+// latest queries: those of the Union, Intersect or Except call that built the
+// CompoundBuilder, or of its latest Union, Intersect or Except method call.
+//
+// Ex:
+//
+//	Union(a, b, c).All() == "a UNION ALL b UNION ALL c"
+//	a.Union(b).Except(c).All() == "a UNION b EXCEPT ALL c"
+func (b CompoundBuilder) All() CompoundBuilder {
+	selects := make([]compoundPart, len(b.data.Selects))
+	copy(selects, b.data.Selects)
+	for idx := b.data.LastCombined; idx < len(selects); idx++ {
+		selects[idx].all = true
+	}
+	b.data.Selects = selects
+	return b
+}
+
+// OrderBy adds ORDER BY expressions to the query, which order the combined
+// rows.
+func (b CompoundBuilder) OrderBy(orderBys ...string) CompoundBuilder {
+	for _, orderBy := range orderBys {
+		b.data.OrderByParts = appendCopy(b.data.OrderByParts, newPart(orderBy))
+	}
+	return b
+}
+
+// Limit sets a LIMIT clause on the query.
+func (b CompoundBuilder) Limit(limit uint64) CompoundBuilder {
+	b.data.Limit = fmt.Sprintf("%d", limit)
+	return b
+}
+
+// Offset sets a OFFSET clause on the query.
+func (b CompoundBuilder) Offset(offset uint64) CompoundBuilder {
+	b.data.Offset = fmt.Sprintf("%d", offset)
+	return b
+}
+
+// Suffix adds an expression to the end of the query
+func (b CompoundBuilder) Suffix(sql string, args ...interface{}) CompoundBuilder {
+	return b.SuffixExpr(Expr(sql, args...))
+}
+
+// SuffixExpr adds an expression to the end of the query
+func (b CompoundBuilder) SuffixExpr(expr Sqlizer) CompoundBuilder {
+	b.data.Suffixes = appendCopy(b.data.Suffixes, expr)
+	return b
+}
@@ -0,0 +1,169 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompoundBuilderToSql(t *testing.T) {
+	users := Select("id", "name").From("users").Where("active = ?", true)
+	archived := Select("id", "name").From("archived_users").Where("archived_at > ?", "2024-01-01")
+
+	sql, args, err := Union(users, archived).
+		Prefix("/* people */").
+		OrderBy("name").
+		Limit(10).
+		Offset(20).
+		Suffix("FETCH FIRST ? ROWS ONLY", 3).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "/* people */ " +
+		"SELECT id, name FROM users WHERE active = $1 " +
+		"UNION SELECT id, name FROM archived_users WHERE archived_at > $2 " +
+		"ORDER BY name LIMIT 10 OFFSET 20 FETCH FIRST $3 ROWS ONLY"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{true, "2024-01-01", 3}, args)
+}
+
+func TestCompoundBuilderOperators(t *testing.T) {
+	a := Select("x").From("a")
+	b := Select("x").From("b")
+	c := Select("x").From("c")
+
+	type testCase struct {
+		builder     CompoundBuilder
+		expectedSql string
+	}
+
+	cases := []testCase{
+		{Union(a, b, c).All(), "SELECT x FROM a UNION ALL SELECT x FROM b UNION ALL SELECT x FROM c"},
+		{UnionAll(a, b), "SELECT x FROM a UNION ALL SELECT x FROM b"},
+		{Intersect(a, b), "SELECT x FROM a INTERSECT SELECT x FROM b"},
+		{Except(a, b), "SELECT x FROM a EXCEPT SELECT x FROM b"},
+		{a.Union(b).UnionAll(c), "SELECT x FROM a UNION SELECT x FROM b UNION ALL SELECT x FROM c"},
+		{a.UnionAll(b).Except(c), "SELECT x FROM a UNION ALL SELECT x FROM b EXCEPT SELECT x FROM c"},
+		{Intersect(a, b, c).All(), "SELECT x FROM a INTERSECT ALL SELECT x FROM b INTERSECT ALL SELECT x FROM c"},
+		{a.Except(b), "SELECT x FROM a EXCEPT SELECT x FROM b"},
+		{a.Union(b).Except(c).All(), "SELECT x FROM a UNION SELECT x FROM b EXCEPT ALL SELECT x FROM c"},
+		{a.Union(b).All().Intersect(c), "(SELECT x FROM a UNION ALL SELECT x FROM b) INTERSECT SELECT x FROM c"},
+	}
+
+	for _, c := range cases {
+		sql, _, err := c.builder.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+	}
+}
+
+func TestCompoundBuilderParens(t *testing.T) {
+	newest := Select("id").From("a").OrderBy("created_at DESC").Limit(5)
+	nested := Intersect(Select("id").From("b"), Select("id").From("c").Where("x = ?", 1))
+
+	sql, args, err := Select("id").From("d").Where("y = ?", 2).
+		Union(newest).
+		Union(nested).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT id FROM d WHERE y = $1 " +
+		"UNION (SELECT id FROM a ORDER BY created_at DESC LIMIT 5) " +
+		"UNION (SELECT id FROM b INTERSECT SELECT id FROM c WHERE x = $2)"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{2, 1}, args)
+}
+
+func TestCompoundBuilderPrecedence(t *testing.T) {
+	a := Select("x").From("a")
+	b := Select("x").From("b")
+	c := Select("x").From("c")
+	d := Select("x").From("d").Where("y = ?", 1)
+
+	type testCase struct {
+		builder     CompoundBuilder
+		expectedSql string
+	}
+
+	cases := []testCase{
+		{a.Union(b).Intersect(c), "(SELECT x FROM a UNION SELECT x FROM b) INTERSECT SELECT x FROM c"},
+		{a.Except(b).Intersect(c), "(SELECT x FROM a EXCEPT SELECT x FROM b) INTERSECT SELECT x FROM c"},
+		{a.Intersect(b).Union(c), "SELECT x FROM a INTERSECT SELECT x FROM b UNION SELECT x FROM c"},
+		{a.Intersect(b).Intersect(c), "SELECT x FROM a INTERSECT SELECT x FROM b INTERSECT SELECT x FROM c"},
+		{a.Union(b).Except(c), "SELECT x FROM a UNION SELECT x FROM b EXCEPT SELECT x FROM c"},
+		{
+			a.Union(b).Intersect(c).Union(d),
+			"(SELECT x FROM a UNION SELECT x FROM b) INTERSECT SELECT x FROM c UNION SELECT x FROM d WHERE y = ?",
+		},
+		{
+			a.Union(b).Intersect(c).Except(d).Intersect(a),
+			"((SELECT x FROM a UNION SELECT x FROM b) INTERSECT SELECT x FROM c EXCEPT SELECT x FROM d WHERE y = ?) INTERSECT SELECT x FROM a",
+		},
+	}
+
+	for _, c := range cases {
+		sql, _, err := c.builder.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+	}
+}
+
+func TestCompoundBuilderSubquery(t *testing.T) {
+	ids := Union(
+		Select("id").From("a").Where("x = ?", 1),
+		Select("id").From("b").Where("x = ?", 2),
+	)
+
+	sql, args, err := Select("*").
+		FromSelect(ids, "ids").
+		Where("id > ?", 3).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM (SELECT id FROM a WHERE x = $1 UNION SELECT id FROM b WHERE x = $2) AS ids WHERE id > $3", sql)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	sql, args, err = Insert("c").
+		Columns("id").
+		Select(ids.Limit(10)).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO c (id) SELECT id FROM a WHERE x = $1 UNION SELECT id FROM b WHERE x = $2 LIMIT 10", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	sql, args, err = Select("id").From("c").Where(Expr("id IN (?)", ids)).Where("y = ?", 3).PlaceholderFormat(Dollar).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM c WHERE id IN (SELECT id FROM a WHERE x = $1 UNION SELECT id FROM b WHERE x = $2) AND y = $3", sql)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+}
+
+func TestCompoundBuilderWithRecursive(t *testing.T) {
+	nums := Select("1").UnionAll(Select("n + 1").From("nums").Where("n < ?", 5))
+
+	sql, args, err := Select("n").WithRecursive("nums", []string{"n"}, nums).From("nums").PlaceholderFormat(Dollar).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH RECURSIVE nums(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM nums WHERE n < $1) SELECT n FROM nums", sql)
+	assert.Equal(t, []interface{}{5}, args)
+}
+
+func TestCompoundBuilderDialect(t *testing.T) {
+	sql, _, err := StatementBuilder.Dialect(SqlServer).
+		Union(Select("x").From("a"), Select("x").From("b").Where("y = ?", 1)).
+		Limit(5).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT x FROM a UNION SELECT x FROM b WHERE y = @p1 ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY", sql)
+}
+
+func TestCompoundBuilderToSqlErr(t *testing.T) {
+	_, _, err := Union(Select("x")).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Union(Select("x"), Select()).ToSql()
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { Union().MustSql() })
+}
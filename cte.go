@@ -0,0 +1,77 @@
+package squirrelly
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// commonTableExpr is a named query in the WITH clause of a statement.
+type commonTableExpr struct {
+	name         string
+	columns      []string
+	query        Subquery
+	recursive    bool
+	materialized bool
+}
+
+// appendWithToSql writes the WITH clause of ctes, followed by a space, to w,
+// rendering queries without a Dialect of their own with dialect d.
+// The queries are rendered without numbering their placeholders, which is
+// left to the statement using them.
+func appendWithToSql(ctes []commonTableExpr, d Dialect, w io.Writer, args []interface{}) ([]interface{}, error) {
+	if len(ctes) == 0 {
+		return args, nil
+	}
+
+	recursive := false
+	for _, cte := range ctes {
+		recursive = recursive || cte.recursive
+	}
+
+	sql := &strings.Builder{}
+	sql.WriteString("WITH ")
+	if recursive {
+		sql.WriteString("RECURSIVE ")
+	}
+
+	for idx, cte := range ctes {
+		if cte.name == "" {
+			return nil, errors.New("common table expressions must have a name")
+		}
+
+		if idx > 0 {
+			sql.WriteString(", ")
+		}
+
+		sql.WriteString(cte.name)
+		if len(cte.columns) > 0 {
+			sql.WriteString("(")
+			sql.WriteString(strings.Join(cte.columns, ", "))
+			sql.WriteString(")")
+		}
+
+		sql.WriteString(" AS ")
+		if cte.materialized {
+			sql.WriteString("MATERIALIZED ")
+		}
+
+		querySql, queryArgs, err := nestedToSql(cte.query, d)
+		if err != nil {
+			return nil, err
+		}
+
+		sql.WriteString("(")
+		sql.WriteString(querySql)
+		sql.WriteString(")")
+		args = append(args, queryArgs...)
+	}
+
+	sql.WriteString(" ")
+
+	_, err := io.WriteString(w, sql.String())
+	if err != nil {
+		return nil, err
+	}
+	return args, nil
+}
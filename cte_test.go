@@ -0,0 +1,128 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectBuilderWith(t *testing.T) {
+	recent := Select("id", "author_id").From("posts").Where("created_at > ?", "2024-01-01")
+	authors := Select("id", "name").From("users").Where(Eq{"active": true})
+
+	sql, args, err := Select("p.id", "a.name").
+		Prefix("/* report */").
+		With("recent", recent).
+		WithMaterialized("authors", authors).
+		From("recent p").
+		Join("authors a ON a.id = p.author_id").
+		Where("p.id > ?", 10).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "/* report */ " +
+		"WITH recent AS (SELECT id, author_id FROM posts WHERE created_at > $1), " +
+		"authors AS MATERIALIZED (SELECT id, name FROM users WHERE active = $2) " +
+		"SELECT p.id, a.name FROM recent p JOIN authors a ON a.id = p.author_id WHERE p.id > $3"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{"2024-01-01", true, 10}, args)
+}
+
+func TestSelectBuilderWithRecursive(t *testing.T) {
+	tree := Select("id", "parent_id").
+		From("nodes").
+		Where("id = ?", 1).
+		Suffix("UNION ALL SELECT n.id, n.parent_id FROM nodes n JOIN tree t ON n.parent_id = t.id WHERE n.depth < ?", 5)
+
+	sql, args, err := Select("id").
+		With("roots", Select("id").From("nodes").Where("parent_id IS NULL")).
+		WithRecursive("tree", []string{"id", "parent_id"}, tree).
+		From("tree").
+		Where("id <> ?", 1).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "WITH RECURSIVE roots AS (SELECT id FROM nodes WHERE parent_id IS NULL), " +
+		"tree(id, parent_id) AS (SELECT id, parent_id FROM nodes WHERE id = $1 " +
+		"UNION ALL SELECT n.id, n.parent_id FROM nodes n JOIN tree t ON n.parent_id = t.id WHERE n.depth < $2) " +
+		"SELECT id FROM tree WHERE id <> $3"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{1, 5, 1}, args)
+}
+
+func TestSelectBuilderWithNested(t *testing.T) {
+	inner := Select("id").
+		With("ids", Select("id").From("t").Where("a = ?", 1)).
+		From("ids")
+
+	sql, args, err := Select("*").
+		FromSelect(inner, "x").
+		Where("b = ?", 2).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM (WITH ids AS (SELECT id FROM t WHERE a = $1) SELECT id FROM ids) AS x WHERE b = $2", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
+
+func TestWithErr(t *testing.T) {
+	_, _, err := Select("*").With("x", Select()).From("x").ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Select("*").With("", Select("1")).ToSql()
+	assert.Error(t, err)
+}
+
+func TestInsertBuilderWith(t *testing.T) {
+	sql, args, err := Insert("archive").
+		With("old", Select("id", "body").From("posts").Where("created_at < ?", "2020-01-01")).
+		Columns("id", "body").
+		Select(Select("id", "body").From("old").Where("body <> ?", "")).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH old AS (SELECT id, body FROM posts WHERE created_at < $1) INSERT INTO archive (id,body) SELECT id, body FROM old WHERE body <> $2", sql)
+	assert.Equal(t, []interface{}{"2020-01-01", ""}, args)
+}
+
+func TestUpdateBuilderWith(t *testing.T) {
+	sql, args, err := Update("users").
+		WithMaterialized("inactive", Select("id").From("logins").GroupBy("id").Having("max(at) < ?", "2020-01-01")).
+		Set("active", false).
+		Where("id IN (SELECT id FROM inactive)").
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH inactive AS MATERIALIZED (SELECT id FROM logins GROUP BY id HAVING max(at) < $1) UPDATE users SET active = $2 WHERE id IN (SELECT id FROM inactive)", sql)
+	assert.Equal(t, []interface{}{"2020-01-01", false}, args)
+}
+
+func TestDeleteBuilderWith(t *testing.T) {
+	children := Select("id").From("nodes").Where("id = ?", 1).Suffix("UNION ALL SELECT n.id FROM nodes n JOIN doomed d ON n.parent_id = d.id")
+
+	sql, args, err := Delete("nodes").
+		WithRecursive("doomed", []string{"id"}, children).
+		Where("id IN (SELECT id FROM doomed)").
+		Where("locked = ?", false).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH RECURSIVE doomed(id) AS (SELECT id FROM nodes WHERE id = $1 UNION ALL SELECT n.id FROM nodes n JOIN doomed d ON n.parent_id = d.id) DELETE FROM nodes WHERE id IN (SELECT id FROM doomed) AND locked = $2", sql)
+	assert.Equal(t, []interface{}{1, false}, args)
+}
+
+func TestMergeBuilderWith(t *testing.T) {
+	sql, args, err := Merge("t").
+		With("s", Select("id", "a").From("staged").Where("batch = ?", 1)).
+		Using("s").
+		On("t.id = s.id").
+		WhenMatched().ThenSet("a", Expr("s.a")).
+		WhenMatched(Expr("t.a = ?", 2)).ThenDelete().
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH s AS (SELECT id, a FROM staged WHERE batch = $1) MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET a = s.a WHEN MATCHED AND t.a = $2 THEN DELETE", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
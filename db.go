@@ -5,17 +5,50 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-
-	"github.com/jmoiron/sqlx/reflectx"
 )
 
 // Type Db is a wrapper around sql.Db
 type Db struct {
 	*sql.DB
+
+	mapper        *Mapper
+	dialect       Dialect
+	stmtCacheSize int
+	stmts         *stmtCache
 }
 
 type Tx struct {
 	*sql.Tx
+
+	mapper  *Mapper
+	dialect Dialect
+	stmts   *stmtCache
+}
+
+// DbOption configures a Db created by [Open].
+type DbOption func(*Db)
+
+// WithMapper sets the mapper used to scan query results into structs, instead of the package level default.
+func WithMapper(m *Mapper) DbOption {
+	return func(db *Db) {
+		db.mapper = m
+	}
+}
+
+// WithDialect sets the dialect of the builders returned by [Db.StatementBuilder] and [Tx.StatementBuilder].
+func WithDialect(d Dialect) DbOption {
+	return func(db *Db) {
+		db.dialect = d
+	}
+}
+
+// WithStatementCache keeps up to size prepared statements, keyed by their generated SQL, and uses them to run
+// the queries of [Db.Exec], [Db.Query], [Db.QueryRow], [Db.Get] and [Db.GetAll], as well as those of its transactions.
+// The least recently used statement is closed when the cache is full. See [Db.StatementCacheStats].
+func WithStatementCache(size int) DbOption {
+	return func(db *Db) {
+		db.stmtCacheSize = size
+	}
 }
 
 // interface for database/sql db like structs
@@ -35,13 +68,60 @@ type DbLike interface {
 }
 
 // Open uses the same convention as [database/sql.Open], a driver name and a source string, both dependant on your driver's package.
-func Open(driver, source string) (*Db, error) {
+func Open(driver, source string, options ...DbOption) (*Db, error) {
 	sqldb, err := sql.Open(driver, source)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Db{sqldb}, nil
+	db := &Db{DB: sqldb}
+	for _, option := range options {
+		option(db)
+	}
+
+	if db.stmtCacheSize > 0 {
+		db.stmts = newStmtCache(sqldb, db.stmtCacheSize)
+	}
+
+	return db, nil
+}
+
+// Close closes any cached statements and the underlying [database/sql.DB].
+func (db *Db) Close() error {
+	if db.stmts != nil {
+		db.stmts.close()
+	}
+	return db.DB.Close()
+}
+
+// StatementCacheStats returns the statistics of the prepared statement cache configured with [WithStatementCache].
+// It returns zero statistics if the cache isn't enabled.
+func (db *Db) StatementCacheStats() StatementCacheStats {
+	if db.stmts == nil {
+		return StatementCacheStats{}
+	}
+	return db.stmts.getStats()
+}
+
+// querier returns the Querier used to run db's queries.
+func (db *Db) querier() Querier {
+	if db.stmts != nil {
+		return cachedQuerier{cache: db.stmts}
+	}
+	return db.DB
+}
+
+// querier returns the Querier used to run tx's queries.
+func (tx *Tx) querier() Querier {
+	if tx.stmts != nil {
+		return cachedQuerier{cache: tx.stmts, tx: tx.Tx}
+	}
+	return tx.Tx
+}
+
+// StatementBuilder returns a StatementBuilderType whose child builders use the same mapper and dialect as db.
+func (db *Db) StatementBuilder() StatementBuilderType {
+	return statementBuilder(db.mapper, db.dialect)
 }
 
 func (db *Db) Begin() (*Tx, error) {
@@ -50,7 +130,7 @@ func (db *Db) Begin() (*Tx, error) {
 		return nil, err
 	}
 
-	return &Tx{tx}, nil
+	return &Tx{Tx: tx, mapper: db.mapper, dialect: db.dialect, stmts: db.stmts}, nil
 }
 
 func (db *Db) WithTx(fn func(DbLike) error) error {
@@ -81,11 +161,24 @@ func Exec(db Querier, query Sqlizer) (sql.Result, error) {
 
 // Exec runs [database/sql.DB.Exec], using a squirrelly builder.
 func (db *Db) Exec(query Sqlizer) (sql.Result, error) {
-	return Exec(db.DB, query)
+	return Exec(db.querier(), query)
+}
+
+// StatementBuilder returns a StatementBuilderType whose child builders use the same mapper and dialect as tx.
+func (tx *Tx) StatementBuilder() StatementBuilderType {
+	return statementBuilder(tx.mapper, tx.dialect)
+}
+
+func statementBuilder(mapper *Mapper, dialect Dialect) StatementBuilderType {
+	b := StatementBuilder.Mapper(mapper)
+	if dialect != nil {
+		b = b.Dialect(dialect)
+	}
+	return b
 }
 
 func (tx *Tx) Exec(query Sqlizer) (sql.Result, error) {
-	return Exec(tx.Tx, query)
+	return Exec(tx.querier(), query)
 }
 
 // Query runs [database/sql.DB.Query] using a squirrelly builder.
@@ -100,11 +193,11 @@ func Query(db Querier, query Sqlizer) (*sql.Rows, error) {
 
 // Query runs [database/sql.DB.Query] using a squirrelly builder.
 func (db *Db) Query(query Sqlizer) (*sql.Rows, error) {
-	return Query(db.DB, query)
+	return Query(db.querier(), query)
 }
 
 func (tx *Tx) Query(query Sqlizer) (*sql.Rows, error) {
-	return Query(tx.Tx, query)
+	return Query(tx.querier(), query)
 }
 
 // QueryRow runs [database/sql.DB.QueryRow] using a squirrelly builder.
@@ -119,11 +212,11 @@ func QueryRow(db Querier, query Sqlizer) *sql.Row {
 
 // QueryRow runs [database/sql.DB.QueryRow] using a squirrelly builder.
 func (db *Db) QueryRow(query Sqlizer) *sql.Row {
-	return QueryRow(db.DB, query)
+	return QueryRow(db.querier(), query)
 }
 
 func (tx *Tx) QueryRow(query Sqlizer) *sql.Row {
-	return QueryRow(tx.Tx, query)
+	return QueryRow(tx.querier(), query)
 }
 
 // Get runs a query using a squirrelly builder (that should return one and only one result), and marshals the result into the data interface.
@@ -137,13 +230,40 @@ func (tx *Tx) Get(query Sqlizer, data any) error {
 	return DbGet(tx, query, data)
 }
 
+// mapperProvider is implemented by DbLikes that have their own mapper.
+type mapperProvider interface {
+	getMapper() *Mapper
+}
+
+func (db *Db) getMapper() *Mapper {
+	if db.mapper != nil {
+		return db.mapper
+	}
+	return getMapper()
+}
+
+func (tx *Tx) getMapper() *Mapper {
+	if tx.mapper != nil {
+		return tx.mapper
+	}
+	return getMapper()
+}
+
+// dbMapper returns the mapper used to scan results from db.
+func dbMapper(db DbLike) *Mapper {
+	if provider, ok := db.(mapperProvider); ok {
+		return provider.getMapper()
+	}
+	return getMapper()
+}
+
 func DbGet(db DbLike, query Sqlizer, data any) error {
 	rows, err := db.Query(query)
 	if err != nil {
 		return err
 	}
 
-	return structScan(rows, data)
+	return structScan(dbMapper(db), rows, data)
 }
 
 // GetAll runs a query using a squirrelly builder, and marshals the resulting records into the data interface.
@@ -158,15 +278,6 @@ func (tx *Tx) GetAll(query Sqlizer, container any) error {
 }
 
 func DbGetAll(db DbLike, query Sqlizer, container any) error {
-	rows, err := db.Query(query)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-
-	// we don't need to error check -- rows can't be closed yet
-	columns, _ := rows.Columns()
-
 	containerValue := reflect.ValueOf(container)
 	if containerValue.Kind() != reflect.Ptr {
 		return errors.New("Container is not a pointer")
@@ -177,6 +288,15 @@ func DbGetAll(db DbLike, query Sqlizer, container any) error {
 		return errors.New("Container is not a pointer to a slice")
 	}
 
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// we don't need to error check -- rows can't be closed yet
+	columns, _ := rows.Columns()
+
 	containerType := containerValue.Type()
 	elemType := containerType.Elem()
 
@@ -189,50 +309,41 @@ func DbGetAll(db DbLike, query Sqlizer, container any) error {
 		elemType = elemType.Elem()
 	}
 
-	mapper := getMapper()
-	fieldTraversals := mapper.TraversalsByName(elemType, columns)
-	for idx, f := range fieldTraversals {
-		if len(f) == 0 {
-			return fmt.Errorf("missing destination name %s in %s", columns[idx], elemType.Name())
+	var plan *scanPlan
+	if len(columns) != 1 || !isScannable(elemType) {
+		plan = dbMapper(db).scanPlan(elemType, columns)
+		if err := plan.validate(); err != nil {
+			return err
 		}
 	}
 
-	rawValues := []reflect.Value{}
+	// values are scanned directly into the new slice, or into newly allocated elements for slices of pointers
+	out := reflect.MakeSlice(containerType, 0, 0)
+	scanTargets := make([]interface{}, len(columns))
 	err = scanRows(rows, func(row *sql.Rows) error {
-		elem := reflect.New(elemType)
-
-		var elemValues []interface{}
-		if len(columns) == 1 && elemType.Kind() != reflect.Struct {
-			elemValues = []interface{}{elem.Interface()}
+		var elem reflect.Value
+		if isPtr {
+			ptr := reflect.New(elemType)
+			out = reflect.Append(out, ptr)
+			elem = ptr.Elem()
 		} else {
-			elemValues = make([]interface{}, len(columns))
-			for idx, traversal := range fieldTraversals {
-				field := reflectx.FieldByIndexes(elem, traversal)
-				elemValues[idx] = field.Addr().Interface()
-			}
-		}
-
-		err := row.Scan(elemValues...)
-		if err != nil {
-			return err
+			out = reflect.Append(out, reflect.Zero(elemType))
+			elem = out.Index(out.Len() - 1)
 		}
 
-		if !isPtr {
-			elem = reflect.Indirect(elem)
+		if plan == nil {
+			scanTargets[0] = elem.Addr().Interface()
+		} else {
+			plan.targets(elem, scanTargets)
 		}
 
-		rawValues = append(rawValues, elem)
-		return nil
+		return row.Scan(scanTargets...)
 	})
 	if err != nil {
 		return err
 	}
 
-	containerValue.Set(reflect.MakeSlice(containerType, len(rawValues), len(rawValues)))
-	for idx, value := range rawValues {
-		containerValue.Index(idx).Set(value)
-	}
-
+	containerValue.Set(out)
 	return nil
 }
 
@@ -262,12 +373,15 @@ func DbGetMap[K comparable, V any](db DbLike, query Sqlizer, keyColumn string) (
 	}
 
 	columns, _ := rows.Columns()
-	mapper := getMapper()
-	fieldTraversals := mapper.TraversalsByName(elemType, columns)
+	mapper := dbMapper(db)
+	plan := mapper.scanPlan(elemType, columns)
+	if err := plan.validate(); err != nil {
+		return nil, err
+	}
 
 	keyIdx := -1
-	for idx, f := range fieldTraversals {
-		if columns[idx] == keyColumn {
+	for idx, f := range plan.fields {
+		if mapper.columnsMatch(columns[idx], keyColumn) {
 			keyIdx = idx
 
 			typ := elemType.FieldByIndex(f).Type
@@ -275,35 +389,24 @@ func DbGetMap[K comparable, V any](db DbLike, query Sqlizer, keyColumn string) (
 				return nil, fmt.Errorf("key column is not of type %v", keyType)
 			}
 		}
-
-		if len(f) == 0 {
-			return nil, fmt.Errorf("missing destination name %s in %s", columns[idx], elemType.Name())
-		}
 	}
 
 	if keyIdx == -1 {
 		return nil, fmt.Errorf("no column found with key %s in %s", keyColumn, elemType.Name())
 	}
 
+	scanTargets := make([]interface{}, len(columns))
 	err = scanRows(rows, func(row *sql.Rows) error {
 		elem := reflect.New(elemType)
+		plan.targets(elem.Elem(), scanTargets)
 
-		elemValues := make([]any, len(columns))
-		var keyValue reflect.Value
-		for idx, traversal := range fieldTraversals {
-			field := reflectx.FieldByIndexes(elem, traversal)
-			elemValues[idx] = field.Addr().Interface()
-
-			if idx == keyIdx {
-				keyValue = field
-			}
-		}
-
-		err := row.Scan(elemValues...)
+		err := row.Scan(scanTargets...)
 		if err != nil {
 			return err
 		}
 
+		keyValue := reflect.ValueOf(scanTargets[keyIdx]).Elem()
+
 		if !isPtr {
 			elem = reflect.Indirect(elem)
 		}
@@ -328,73 +431,3 @@ func DbGetMap[K comparable, V any](db DbLike, query Sqlizer, keyColumn string) (
 
 	return out.Interface().(map[K]V), nil
 }
-
-func scanRows(rows *sql.Rows, fn func(*sql.Rows) error) error {
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Err()
-		if err != nil {
-			return err
-		}
-
-		err = fn(rows)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func structScan(rows *sql.Rows, destination interface{}) error {
-	tryScan := false
-
-	dest := reflect.ValueOf(destination)
-	if dest.Kind() != reflect.Ptr {
-		return errors.New("destination is not a pointer")
-	}
-	typ := dest.Elem().Type()
-	if typ.Kind() != reflect.Struct {
-		tryScan = true
-		//return errors.New("destination is not a struct")
-	}
-
-	defer rows.Close()
-	if !rows.Next() {
-		return sql.ErrNoRows
-	}
-
-	if tryScan {
-		if err := rows.Scan(destination); err != nil {
-			return errors.New("destination is not a struct, and could not be scanned")
-		}
-		return nil
-	}
-
-	// value := reflect.New(typ)
-	columns, _ := rows.Columns()
-
-	mapper := getMapper()
-	fieldTraversals := mapper.TraversalsByName(typ, columns)
-
-	scanInterfaces := make([]interface{}, len(columns))
-	for idx, f := range fieldTraversals {
-		if len(f) == 0 {
-			return fmt.Errorf("missing destination name %s in %s", columns[idx], typ.Name())
-		}
-
-		field := reflectx.FieldByIndexes(dest, f)
-		scanInterfaces[idx] = field.Addr().Interface()
-	}
-
-	err := rows.Scan(scanInterfaces...)
-	if err != nil {
-		return err
-	}
-
-	if rows.Next() {
-		return errors.New("trying to scan multiple rows into a single struct")
-	}
-
-	return nil
-}
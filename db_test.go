@@ -2,6 +2,8 @@ package squirrelly_test
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	sq "github.com/sleepdeprecation/squirrelly"
@@ -180,3 +182,440 @@ func ExampleDb() {
 
 	fmt.Printf("%+v\n", values)
 }
+
+func TestDbGetAllColumnsForJoin(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:")
+
+	_, err := db.DB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)")
+	assert.NoError(t, err)
+	_, err = db.DB.Exec("CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER NOT NULL, title TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	type user struct {
+		Id   int    `sq:"id"`
+		Name string `sq:"name"`
+	}
+
+	type post struct {
+		Id       int    `sq:"id"`
+		AuthorId int    `sq:"author_id"`
+		Title    string `sq:"title"`
+	}
+
+	type postWithAuthor struct {
+		Post   post  `sq:"p"`
+		Author *user `sq:"u"`
+	}
+
+	_, err = db.Exec(sq.Insert("users").Struct(&user{Id: 1, Name: "ada"}))
+	assert.NoError(t, err)
+	_, err = db.Exec(sq.Insert("posts").Struct(&post{Id: 10, AuthorId: 1, Title: "hello"}))
+	assert.NoError(t, err)
+
+	query := sq.Select().
+		ColumnsFor(post{}, "p").
+		ColumnsFor(user{}, "u").
+		From("posts p").
+		Join("users u ON u.id = p.author_id")
+
+	records := []postWithAuthor{}
+	assert.NoError(t, db.GetAll(query, &records))
+	assert.Equal(t, []postWithAuthor{
+		{Post: post{Id: 10, AuthorId: 1, Title: "hello"}, Author: &user{Id: 1, Name: "ada"}},
+	}, records)
+
+	record := postWithAuthor{}
+	assert.NoError(t, db.Get(query.Where(sq.Eq{"p.id": 10}), &record))
+	assert.Equal(t, "ada", record.Author.Name)
+}
+
+func TestDbWithMapper(t *testing.T) {
+	mapper := sq.NewMapper(sq.WithTagName("db"), sq.WithNameMapper(sq.SnakeCase), sq.WithCaseInsensitiveColumns())
+	db, _ := sq.Open("sqlite", "file::memory:", sq.WithMapper(mapper))
+
+	_, err := db.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, comment_text TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	type foo struct {
+		Pk          int `db:"pk"`
+		CommentText string
+	}
+
+	_, err = db.Exec(sq.Insert("foo").Columns("pk", "comment_text").Values(1, "first").Values(2, "second"))
+	assert.NoError(t, err)
+
+	records := []foo{}
+	assert.NoError(t, db.GetAll(sq.Select("pk AS PK", "comment_text AS COMMENT_TEXT").From("foo"), &records))
+	assert.Equal(t, []foo{{Pk: 1, CommentText: "first"}, {Pk: 2, CommentText: "second"}}, records)
+
+	record := foo{}
+	assert.NoError(t, db.WithTx(func(tx sq.DbLike) error {
+		return tx.Get(sq.Select("*").From("foo").Where(sq.Eq{"pk": 2}), &record)
+	}))
+	assert.Equal(t, foo{Pk: 2, CommentText: "second"}, record)
+
+	byPk, err := sq.DbGetMap[int, foo](db, sq.Select("pk AS PK", "comment_text").From("foo"), "pk")
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]foo{1: {Pk: 1, CommentText: "first"}, 2: {Pk: 2, CommentText: "second"}}, byPk)
+
+	// the default mapper uses the sq tag and exact field names
+	defaultDb := &sq.Db{DB: db.DB}
+	assert.Error(t, defaultDb.GetAll(sq.Select("*").From("foo"), &records))
+}
+
+func TestDbWithDialect(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:", sq.WithDialect(sq.Sqlite))
+
+	_, err := db.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, comment TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	type foo struct {
+		Pk      int    `sq:"pk"`
+		Comment string `sq:"comment"`
+	}
+
+	sb := db.StatementBuilder()
+
+	inserted := foo{}
+	insert := sb.Insert("foo").Columns("pk", "comment").Values(1, "first").Returning("pk", "comment")
+	assert.NoError(t, db.Get(insert, &inserted))
+	assert.Equal(t, foo{Pk: 1, Comment: "first"}, inserted)
+
+	_, err = db.Exec(sb.Insert("foo").Columns("pk", "comment").Values(2, "second").Values(3, "third"))
+	assert.NoError(t, err)
+
+	// sqlite rejects a bare OFFSET, so the dialect adds LIMIT -1
+	records := []foo{}
+	assert.NoError(t, db.GetAll(sb.Select("*").From("foo").OrderBy("pk").Offset(1), &records))
+	assert.Equal(t, []foo{{Pk: 2, Comment: "second"}, {Pk: 3, Comment: "third"}}, records)
+
+	// sqlite has no row locks, so the dialect leaves FOR UPDATE out
+	assert.NoError(t, db.Get(sb.Select("*").From("foo").Where(sq.Eq{"pk": 1}).ForUpdate().SkipLocked(), &inserted))
+	assert.Equal(t, foo{Pk: 1, Comment: "first"}, inserted)
+
+	assert.NoError(t, db.WithTx(func(tx sq.DbLike) error {
+		return tx.GetAll(tx.(*sq.Tx).StatementBuilder().Delete("foo").Where(sq.Eq{"pk": 3}).Returning("pk", "comment"), &records)
+	}))
+	assert.Equal(t, []foo{{Pk: 3, Comment: "third"}}, records)
+}
+
+func TestDbUpsert(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:", sq.WithDialect(sq.Sqlite))
+
+	_, err := db.DB.Exec("CREATE TABLE counters (name TEXT PRIMARY KEY, counter INTEGER NOT NULL, locked INTEGER NOT NULL)")
+	assert.NoError(t, err)
+
+	sb := db.StatementBuilder()
+	upsert := func(name string, counter int) error {
+		_, err := db.Exec(sb.Insert("counters").
+			Columns("name", "counter", "locked").
+			Values(name, counter, 0).
+			OnConflict("name").
+			UpdateSet("counter", sq.Expr("counters.counter + EXCLUDED.counter")).
+			UpdateWhere("counters.locked = ?", 0))
+		return err
+	}
+
+	assert.NoError(t, upsert("visits", 1))
+	assert.NoError(t, upsert("visits", 2))
+	assert.NoError(t, upsert("locked", 1))
+
+	_, err = db.Exec(sb.Update("counters").Set("locked", 1).Where(sq.Eq{"name": "locked"}))
+	assert.NoError(t, err)
+	assert.NoError(t, upsert("locked", 5))
+
+	type counter struct {
+		Name    string `sq:"name"`
+		Counter int    `sq:"counter"`
+	}
+
+	counters, err := sq.DbGetMap[string, counter](db, sb.Select("name", "counter").From("counters"), "name")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]counter{"visits": {"visits", 3}, "locked": {"locked", 1}}, counters)
+}
+
+func TestDbWithRecursive(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:", sq.WithDialect(sq.Postgres))
+
+	nums := sq.Select("?").Suffix("UNION ALL SELECT n + 1 FROM nums WHERE n < ?", 1, 5)
+
+	// Postgres style placeholders are numbered once across the CTE and the outer query, which sqlite also accepts
+	values := []int{}
+	query := db.StatementBuilder().Select("n").WithRecursive("nums", []string{"n"}, nums).From("nums").Where("n > ?", 2)
+	assert.NoError(t, db.GetAll(query, &values))
+	assert.Equal(t, []int{3, 4, 5}, values)
+
+	sb := db.StatementBuilder()
+	compound := sb.Select("n").From("nums").Where("n < ?", 3).
+		Union(sb.Select("n").From("nums").Where("n > ?", 4)).
+		OrderBy("n DESC")
+	assert.NoError(t, db.GetAll(sb.Select("n").WithRecursive("nums", []string{"n"}, nums).FromSelect(compound, "c"), &values))
+	assert.Equal(t, []int{5, 2, 1}, values)
+}
+
+func TestDbCompoundSqlite(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:", sq.WithDialect(sq.Sqlite))
+
+	_, err := db.DB.Exec("CREATE TABLE nums (n INTEGER NOT NULL)")
+	assert.NoError(t, err)
+	_, err = db.Exec(sq.Insert("nums").Columns("n").Values(1).Values(2).Values(3))
+	assert.NoError(t, err)
+
+	// sqlite applies the operators from left to right, without parentheses
+	sb := db.StatementBuilder()
+	query := sb.Select("n").From("nums").Where("n < ?", 3).
+		Union(sb.Select("n").From("nums").Where("n > ?", 2)).
+		Intersect(sb.Select("n").From("nums").Where("n <> ?", 2)).
+		OrderBy("n")
+
+	values := []int{}
+	assert.NoError(t, db.GetAll(query, &values))
+	assert.Equal(t, []int{1, 3}, values)
+}
+
+func TestDbWindow(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:")
+
+	_, err := db.DB.Exec("CREATE TABLE sales (pk INTEGER PRIMARY KEY, region TEXT NOT NULL, amount INTEGER NOT NULL)")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(sq.Insert("sales").Columns("pk", "region", "amount").Values(1, "east", 10).Values(2, "east", 20).Values(3, "west", 5))
+	assert.NoError(t, err)
+
+	type total struct {
+		Pk    int `sq:"pk"`
+		Rank  int `sq:"rank"`
+		Total int `sq:"total"`
+	}
+
+	totals := []total{}
+	query := sq.Select("pk").
+		Column(sq.Alias(sq.Window("row_number()").Over("w"), "rank")).
+		Column(sq.Alias(sq.Window("sum(amount)").Over("w").Rows(sq.UnboundedPreceding, sq.CurrentRow), "total")).
+		From("sales").
+		Window("w", sq.WindowSpec().PartitionBy("region").OrderBy("pk")).
+		OrderBy("pk")
+	assert.NoError(t, db.GetAll(query, &totals))
+	assert.Equal(t, []total{{1, 1, 10}, {2, 2, 30}, {3, 1, 5}}, totals)
+}
+
+func TestDbUpdateFromJoin(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:", sq.WithDialect(sq.Sqlite))
+
+	_, err := db.DB.Exec("CREATE TABLE accounts (pk INTEGER PRIMARY KEY, plan_pk INTEGER NOT NULL, quota INTEGER NOT NULL)")
+	assert.NoError(t, err)
+	_, err = db.DB.Exec("CREATE TABLE plans (pk INTEGER PRIMARY KEY, tier_pk INTEGER NOT NULL)")
+	assert.NoError(t, err)
+	_, err = db.DB.Exec("CREATE TABLE tiers (pk INTEGER PRIMARY KEY, quota INTEGER NOT NULL)")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(db.StatementBuilder().Insert("tiers").Columns("pk", "quota").Values(1, 10).Values(2, 100))
+	assert.NoError(t, err)
+	_, err = db.Exec(db.StatementBuilder().Insert("plans").Columns("pk", "tier_pk").Values(1, 1).Values(2, 2))
+	assert.NoError(t, err)
+	_, err = db.Exec(db.StatementBuilder().Insert("accounts").Columns("pk", "plan_pk", "quota").Values(1, 1, 0).Values(2, 2, 0))
+	assert.NoError(t, err)
+
+	_, err = db.Exec(db.StatementBuilder().Update("accounts").
+		Set("quota", sq.Expr("tiers.quota")).
+		From("plans").
+		JoinOn("tiers", sq.Expr("tiers.pk = plans.tier_pk")).
+		Where("plans.pk = accounts.plan_pk").
+		Where("tiers.quota > ?", 50))
+	assert.NoError(t, err)
+
+	type account struct {
+		Pk    int `sq:"pk"`
+		Quota int `sq:"quota"`
+	}
+
+	accounts := []account{}
+	assert.NoError(t, db.GetAll(db.StatementBuilder().Select("pk", "quota").From("accounts").OrderBy("pk"), &accounts))
+	assert.Equal(t, []account{{1, 0}, {2, 100}}, accounts)
+}
+
+func TestDbTupleExpansion(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:")
+
+	_, err := db.DB.Exec("CREATE TABLE grid (pk INTEGER PRIMARY KEY, a INTEGER NOT NULL, b INTEGER NOT NULL)")
+	assert.NoError(t, err)
+
+	insert := sq.Insert("grid").Columns("a", "b")
+	for a := 0; a < 3; a++ {
+		for b := 0; b < 3; b++ {
+			insert = insert.Values(a, b)
+		}
+	}
+	_, err = db.Exec(insert)
+	assert.NoError(t, err)
+
+	type cell struct {
+		Pk int `sq:"pk"`
+	}
+
+	// sqlite compares row values, so the expansion used for SQL Server must
+	// match the same cells
+	tuple := sq.Tuple("a", "b")
+	comparisons := map[string]func(sq.TupleBuilder) sq.Sqlizer{
+		"eq":     func(t sq.TupleBuilder) sq.Sqlizer { return t.Eq(1, 1) },
+		"not eq": func(t sq.TupleBuilder) sq.Sqlizer { return t.NotEq(1, 1) },
+		"lt":     func(t sq.TupleBuilder) sq.Sqlizer { return t.Lt(1, 1) },
+		"lt eq":  func(t sq.TupleBuilder) sq.Sqlizer { return t.LtOrEq(1, 1) },
+		"gt":     func(t sq.TupleBuilder) sq.Sqlizer { return t.Gt(1, 1) },
+		"gt eq":  func(t sq.TupleBuilder) sq.Sqlizer { return t.GtOrEq(1, 1) },
+		"in":     func(t sq.TupleBuilder) sq.Sqlizer { return t.In([][]interface{}{{0, 2}, {1, 1}}) },
+		"not in": func(t sq.TupleBuilder) sq.Sqlizer { return t.NotIn([][]interface{}{{0, 2}, {1, 1}}) },
+	}
+
+	for name, compare := range comparisons {
+		want := []cell{}
+		query := sq.Select("pk").From("grid").Where(compare(tuple)).OrderBy("pk")
+		assert.NoError(t, db.GetAll(query, &want), name)
+
+		got := []cell{}
+		expanded := query.Dialect(sq.SqlServer).PlaceholderFormat(sq.Question)
+		assert.NoError(t, db.GetAll(expanded, &got), name)
+
+		assert.NotEmpty(t, want, name)
+		assert.Equal(t, want, got, name)
+	}
+}
+
+func TestDbAggregateFilterFallback(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:")
+
+	_, err := db.DB.Exec("CREATE TABLE scores (pk INTEGER PRIMARY KEY, team TEXT NOT NULL, score INTEGER)")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(sq.Insert("scores").Columns("team", "score").
+		Values("a", 1).
+		Values("a", 5).
+		Values("a", nil).
+		Values("b", 3).
+		Values("b", 3))
+	assert.NoError(t, err)
+
+	type totals struct {
+		Team     string `sq:"team"`
+		Count    int    `sq:"n"`
+		Sum      *int   `sq:"total"`
+		Distinct int    `sq:"uniq"`
+	}
+
+	// sqlite has FILTER clauses, so the CASE WHEN fallback used for MySQL must
+	// aggregate the same rows
+	query := sq.Select("team").
+		Column(sq.Alias(sq.Count(sq.Col("*")).Filter("score > ?", 1), "n")).
+		Column(sq.Alias(sq.Sum(sq.Col("score")).Filter("score > ?", 1), "total")).
+		Column(sq.Alias(sq.Count(sq.Col("score")).Distinct().Filter("score < ?", 5), "uniq")).
+		From("scores").
+		GroupBy("team").
+		OrderBy("team")
+
+	want := []totals{}
+	assert.NoError(t, db.GetAll(query.Dialect(sq.Sqlite), &want))
+
+	got := []totals{}
+	assert.NoError(t, db.GetAll(query.Dialect(sq.MySql), &got))
+
+	five, six := 5, 6
+	assert.Equal(t, []totals{{"a", 1, &five, 1}, {"b", 2, &six, 1}}, want)
+	assert.Equal(t, want, got)
+}
+
+func TestDbLikeEscaping(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:")
+
+	_, err := db.DB.Exec("CREATE TABLE products (pk INTEGER PRIMARY KEY, name TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(sq.Insert("products").Columns("pk", "name").
+		Values(1, "50% off").
+		Values(2, "500 items").
+		Values(3, "a_b").
+		Values(4, "axb").
+		Values(5, "A_B!"))
+	assert.NoError(t, err)
+
+	type product struct {
+		Pk int `sq:"pk"`
+	}
+
+	// sqlite's LIKE ignores the case of ASCII letters
+	cases := map[sq.Sqlizer][]product{
+		sq.Contains("name", "50%"):         {{1}},
+		sq.StartsWith("name", "a_"):        {{3}, {5}},
+		sq.IStartsWith("name", "a_"):       {{3}, {5}},
+		sq.IEndsWith("name", "_b!"):        {{5}},
+		sq.IContains("name", "X"):          {{4}},
+		sq.EndsWith("name", "items"):       {{2}},
+		sq.Not(sq.Contains("name", "_")):   {{1}, {2}, {4}},
+		sq.Not(sq.IContains("name", "AX")): {{1}, {2}, {3}, {5}},
+	}
+
+	for pred, want := range cases {
+		got := []product{}
+		assert.NoError(t, db.GetAll(sq.Select("pk").From("products").Where(pred).OrderBy("pk").Dialect(sq.Sqlite), &got))
+		assert.Equal(t, want, got, sq.DebugSqlizer(pred))
+	}
+}
+
+func TestDbMapperConcurrency(t *testing.T) {
+	type foo struct {
+		Pk          int `db:"pk"`
+		CommentText string
+	}
+
+	snakeDb, _ := sq.Open("sqlite", "file:snake?mode=memory&cache=shared", sq.WithMapper(sq.NewMapper(sq.WithTagName("db"), sq.WithNameMapper(sq.SnakeCase))))
+	defer snakeDb.Close()
+	lowerDb, _ := sq.Open("sqlite", "file:lower?mode=memory&cache=shared", sq.WithMapper(sq.NewMapper(sq.WithTagName("db"), sq.WithNameMapper(strings.ToLower))))
+	defer lowerDb.Close()
+
+	_, err := snakeDb.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, comment_text TEXT NOT NULL)")
+	assert.NoError(t, err)
+	_, err = lowerDb.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, commenttext TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, db := range []*sq.Db{snakeDb, lowerDb} {
+			wg.Add(1)
+			go func(db *sq.Db, i int) {
+				defer wg.Done()
+
+				_, err := db.Exec(db.StatementBuilder().Insert("foo").Struct(&foo{Pk: i, CommentText: fmt.Sprint(i)}))
+				assert.NoError(t, err)
+
+				record := foo{}
+				assert.NoError(t, db.Get(sq.Select("*").From("foo").Where(sq.Eq{"pk": i}), &record))
+				assert.Equal(t, foo{Pk: i, CommentText: fmt.Sprint(i)}, record)
+			}(db, i)
+		}
+	}
+	wg.Wait()
+}
+
+func TestDbGetAllScalars(t *testing.T) {
+	db, _ := sq.Open("sqlite", "file::memory:")
+
+	_, err := db.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, comment TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(sq.Insert("foo").Columns("pk", "comment").Values(1, "first").Values(2, "second"))
+	assert.NoError(t, err)
+
+	comments := []string{}
+	assert.NoError(t, db.GetAll(sq.Select("comment").From("foo").OrderBy("pk"), &comments))
+	assert.Equal(t, []string{"first", "second"}, comments)
+
+	pks := []*int{}
+	assert.NoError(t, db.GetAll(sq.Select("pk").From("foo").OrderBy("pk"), &pks))
+	one, two := 1, 2
+	assert.Equal(t, []*int{&one, &two}, pks)
+
+	empty := []string{}
+	assert.NoError(t, db.GetAll(sq.Select("comment").From("foo").Where(sq.Eq{"pk": 3}), &empty))
+	assert.Equal(t, []string{}, empty)
+
+	assert.Error(t, db.GetAll(sq.Select("comment").From("foo"), comments))
+}
@@ -4,18 +4,22 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
-
-	"github.com/lann/builder"
 )
 
 type deleteData struct {
 	PlaceholderFormat PlaceholderFormat
+	Dialect           Dialect
+	Mapper            *Mapper
 	Prefixes          []Sqlizer
+	With              []commonTableExpr
 	From              string
+	Using             []Sqlizer
+	Joins             []Sqlizer
 	WhereParts        []Sqlizer
 	OrderBys          []string
 	Limit             string
 	Offset            string
+	Returning         []string
 	Suffixes          []Sqlizer
 }
 
@@ -25,10 +29,37 @@ func (d *deleteData) ToSql() (sqlStr string, args []interface{}, err error) {
 		return
 	}
 
+	multiTable := len(d.Using) > 0 || len(d.Joins) > 0
+	var multiTableStyle MultiTableStyle
+	if multiTable {
+		multiTableStyle, err = dialectOrDefault(d.Dialect).MultiTable(DeleteStatement)
+		if err != nil {
+			return
+		}
+		if multiTableStyle == MultiTableFrom && len(d.Using) == 0 {
+			err = fmt.Errorf("delete statements with joins must have a Using table to join to")
+			return
+		}
+	}
+
+	limit, top, err := dialectOrDefault(d.Dialect).UpdateLimit(d.Limit, d.Offset)
+	if err != nil {
+		return
+	}
+
+	var returning string
+	var output bool
+	if len(d.Returning) > 0 {
+		returning, output, err = dialectOrDefault(d.Dialect).Returning(d.Returning, "DELETED")
+		if err != nil {
+			return
+		}
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
-		args, err = appendToSql(d.Prefixes, sql, " ", args)
+		args, err = appendToSql(d.Prefixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
@@ -36,12 +67,62 @@ func (d *deleteData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(" ")
 	}
 
-	sql.WriteString("DELETE FROM ")
-	sql.WriteString(d.From)
+	args, err = appendWithToSql(d.With, d.Dialect, sql, args)
+	if err != nil {
+		return
+	}
+
+	if multiTable && multiTableStyle == MultiTableJoin {
+		// the target is named by its alias, and joined to the other tables after FROM
+		sql.WriteString("DELETE ")
+		if top {
+			sql.WriteString(limit)
+			sql.WriteString(" ")
+		}
+		sql.WriteString(tableAlias(d.From))
+
+		if output {
+			sql.WriteString(" ")
+			sql.WriteString(returning)
+		}
+
+		sql.WriteString(" FROM ")
+		sql.WriteString(d.From)
+		if len(d.Using) > 0 {
+			sql.WriteString(", ")
+		} else {
+			sql.WriteString(" ")
+		}
+		args, err = appendTablesToSql(d.Using, d.Joins, d.Dialect, sql, args)
+		if err != nil {
+			return
+		}
+	} else {
+		sql.WriteString("DELETE ")
+		if top {
+			sql.WriteString(limit)
+			sql.WriteString(" ")
+		}
+		sql.WriteString("FROM ")
+		sql.WriteString(d.From)
+
+		if output {
+			sql.WriteString(" ")
+			sql.WriteString(returning)
+		}
+
+		if multiTable {
+			sql.WriteString(" USING ")
+			args, err = appendTablesToSql(d.Using, d.Joins, d.Dialect, sql, args)
+			if err != nil {
+				return
+			}
+		}
+	}
 
 	if len(d.WhereParts) > 0 {
 		sql.WriteString(" WHERE ")
-		args, err = appendToSql(d.WhereParts, sql, " AND ", args)
+		args, err = appendToSql(d.WhereParts, d.Dialect, sql, " AND ", args)
 		if err != nil {
 			return
 		}
@@ -52,35 +133,33 @@ func (d *deleteData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(strings.Join(d.OrderBys, ", "))
 	}
 
-	if len(d.Limit) > 0 {
-		sql.WriteString(" LIMIT ")
-		sql.WriteString(d.Limit)
+	if limit != "" && !top {
+		sql.WriteString(" ")
+		sql.WriteString(limit)
 	}
 
-	if len(d.Offset) > 0 {
-		sql.WriteString(" OFFSET ")
-		sql.WriteString(d.Offset)
+	if returning != "" && !output {
+		sql.WriteString(" ")
+		sql.WriteString(returning)
 	}
 
 	if len(d.Suffixes) > 0 {
 		sql.WriteString(" ")
-		args, err = appendToSql(d.Suffixes, sql, " ", args)
+		args, err = appendToSql(d.Suffixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, d.Dialect, sql.String())
 	return
 }
 
 // Builder
 
 // DeleteBuilder builds SQL DELETE statements.
-type DeleteBuilder builder.Builder
-
-func init() {
-	builder.Register(DeleteBuilder{}, deleteData{})
+type DeleteBuilder struct {
+	data deleteData
 }
 
 // Format methods
@@ -88,15 +167,34 @@ func init() {
 // PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
 // query.
 func (b DeleteBuilder) PlaceholderFormat(f PlaceholderFormat) DeleteBuilder {
-	return builder.Set(b, "PlaceholderFormat", f).(DeleteBuilder)
+	b.data.PlaceholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect used to render the query, along with its
+// PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b DeleteBuilder) Dialect(d Dialect) DeleteBuilder {
+	b.data.Dialect = d
+	b.data.PlaceholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// Mapper sets the Mapper used to map struct fields to columns, instead of the
+// package level default.
+func (b DeleteBuilder) Mapper(m *Mapper) DeleteBuilder {
+	b.data.Mapper = m
+	return b
 }
 
 // SQL methods
 
 // ToSql builds the query into a SQL string and bound args.
 func (b DeleteBuilder) ToSql() (string, []interface{}, error) {
-	data := builder.GetStruct(b).(deleteData)
-	return data.ToSql()
+	return b.data.ToSql()
+}
+
+func (b DeleteBuilder) debugFormat() (PlaceholderFormat, Dialect) {
+	return b.data.PlaceholderFormat, b.data.Dialect
 }
 
 // MustSql builds the query into a SQL string and bound args.
@@ -116,34 +214,133 @@ func (b DeleteBuilder) Prefix(sql string, args ...interface{}) DeleteBuilder {
 
 // PrefixExpr adds an expression to the very beginning of the query
 func (b DeleteBuilder) PrefixExpr(expr Sqlizer) DeleteBuilder {
-	return builder.Append(b, "Prefixes", expr).(DeleteBuilder)
+	b.data.Prefixes = appendCopy(b.data.Prefixes, expr)
+	return b
+}
+
+// With adds a common table expression to the WITH clause of the query.
+//
+// See SelectBuilder.With for more information.
+func (b DeleteBuilder) With(name string, query Subquery) DeleteBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression to the WITH clause
+// of the query.
+//
+// See SelectBuilder.WithRecursive for more information.
+func (b DeleteBuilder) WithRecursive(name string, columns []string, query Subquery) DeleteBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, columns: columns, query: query, recursive: true})
+	return b
+}
+
+// WithMaterialized adds a materialized common table expression to the WITH
+// clause of the query.
+//
+// See SelectBuilder.WithMaterialized for more information.
+func (b DeleteBuilder) WithMaterialized(name string, query Subquery) DeleteBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query, materialized: true})
+	return b
 }
 
 // From sets the table to be deleted from.
 func (b DeleteBuilder) From(from string) DeleteBuilder {
-	return builder.Set(b, "From", from).(DeleteBuilder)
+	b.data.From = from
+	return b
+}
+
+// Using adds tables to the USING clause of the query, which lists the tables
+// the delete reads from besides its target table.
+//
+// The tables and joins of the query are rendered as the builder's Dialect
+// expects: in a USING clause after the target table, or MySQL style, alongside
+// the target table as in DELETE t FROM t, tables JOIN .... The MySQL style
+// names the target table by its alias, if From was given one.
+func (b DeleteBuilder) Using(tables ...string) DeleteBuilder {
+	for _, table := range tables {
+		b.data.Using = appendCopy(b.data.Using, newPart(table))
+	}
+	return b
+}
+
+// JoinClause adds a join clause to the query.
+//
+// See DeleteBuilder.Using for how joins are rendered.
+func (b DeleteBuilder) JoinClause(pred interface{}, args ...interface{}) DeleteBuilder {
+	b.data.Joins = appendCopy(b.data.Joins, newPart(pred, args...))
+	return b
+}
+
+// Join adds a JOIN clause to the query.
+func (b DeleteBuilder) Join(join string, rest ...interface{}) DeleteBuilder {
+	return b.JoinClause("JOIN "+join, rest...)
+}
+
+// LeftJoin adds a LEFT JOIN clause to the query.
+func (b DeleteBuilder) LeftJoin(join string, rest ...interface{}) DeleteBuilder {
+	return b.JoinClause("LEFT JOIN "+join, rest...)
+}
+
+// JoinOn adds a JOIN clause to the query, joining table on the condition on.
+func (b DeleteBuilder) JoinOn(table string, on Sqlizer) DeleteBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: newPart(table), on: on})
+}
+
+// JoinSelect adds a JOIN clause to the query, joining the subquery sub, named
+// alias, on the condition on.
+func (b DeleteBuilder) JoinSelect(sub Subquery, alias string, on Sqlizer) DeleteBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: Alias(sub, alias), on: on})
 }
 
 // Where adds WHERE expressions to the query.
 //
 // See SelectBuilder.Where for more information.
 func (b DeleteBuilder) Where(pred interface{}, args ...interface{}) DeleteBuilder {
-	return builder.Append(b, "WhereParts", newWherePart(pred, args...)).(DeleteBuilder)
+	b.data.WhereParts = appendCopy(b.data.WhereParts, newWherePart(pred, args...))
+	return b
+}
+
+// WhereStruct adds an expression to the WHERE clause of the query, built from
+// the fields of a filter struct.
+//
+// See SelectBuilder.WhereStruct for more information.
+func (b DeleteBuilder) WhereStruct(filter interface{}) DeleteBuilder {
+	pred, err := newStructWherePart(mapperOrDefault(b.data.Mapper), filter)
+	if err != nil {
+		panic(err)
+	}
+	if pred == nil {
+		return b
+	}
+	return b.Where(pred)
 }
 
 // OrderBy adds ORDER BY expressions to the query.
 func (b DeleteBuilder) OrderBy(orderBys ...string) DeleteBuilder {
-	return builder.Extend(b, "OrderBys", orderBys).(DeleteBuilder)
+	b.data.OrderBys = appendCopy(b.data.OrderBys, orderBys...)
+	return b
 }
 
-// Limit sets a LIMIT clause on the query.
+// Limit sets a LIMIT clause on the query, or a TOP clause with SqlServer.
+// Postgres doesn't limit the rows of update and delete statements, so it's an
+// error with that Dialect.
 func (b DeleteBuilder) Limit(limit uint64) DeleteBuilder {
-	return builder.Set(b, "Limit", fmt.Sprintf("%d", limit)).(DeleteBuilder)
+	b.data.Limit = fmt.Sprintf("%d", limit)
+	return b
 }
 
 // Offset sets a OFFSET clause on the query.
 func (b DeleteBuilder) Offset(offset uint64) DeleteBuilder {
-	return builder.Set(b, "Offset", fmt.Sprintf("%d", offset)).(DeleteBuilder)
+	b.data.Offset = fmt.Sprintf("%d", offset)
+	return b
+}
+
+// Returning adds a RETURNING <columns> clause (before the [DeleteBuilder.Suffix])
+// to the query, or an OUTPUT clause for dialects that use one instead.
+func (b DeleteBuilder) Returning(columns ...string) DeleteBuilder {
+	b.data.Returning = appendCopy(b.data.Returning, columns...)
+	return b
 }
 
 // Suffix adds an expression to the end of the query
@@ -153,5 +350,6 @@ func (b DeleteBuilder) Suffix(sql string, args ...interface{}) DeleteBuilder {
 
 // SuffixExpr adds an expression to the end of the query
 func (b DeleteBuilder) SuffixExpr(expr Sqlizer) DeleteBuilder {
-	return builder.Append(b, "Suffixes", expr).(DeleteBuilder)
+	b.data.Suffixes = appendCopy(b.data.Suffixes, expr)
+	return b
 }
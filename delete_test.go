@@ -52,3 +52,60 @@ func TestDeleteBuilderPlaceholders(t *testing.T) {
 	sql, _, _ = b.PlaceholderFormat(Dollar).ToSql()
 	assert.Equal(t, "DELETE FROM test WHERE x = $1 AND y = $2", sql)
 }
+
+func TestDeleteBuilderWhereStruct(t *testing.T) {
+	filter := struct {
+		Status string `sq:"status,op=ne"`
+		Name   string `sq:"name,op=notlike"`
+	}{Status: "active", Name: "keep%"}
+
+	sql, args, err := Delete("jobs").WhereStruct(&filter).PlaceholderFormat(Dollar).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM jobs WHERE (status <> $1 AND name NOT LIKE $2)", sql)
+	assert.Equal(t, []interface{}{"active", "keep%"}, args)
+}
+
+func BenchmarkDeleteBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Delete("t").
+			Where(Eq{"id": i}).
+			Where("deleted_at IS NOT NULL").
+			Limit(10).
+			ToSql()
+	}
+}
+
+func TestDeleteBuilderUsingJoins(t *testing.T) {
+	sql, args, err := Delete("sessions s").
+		Using("users u").
+		JoinOn("orgs o", Eq{"o.disabled": true}).
+		Where("s.user_id = u.id AND u.org_id = o.id").
+		Where("s.created_at < ?", "2024-01-01").
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM sessions s USING users u JOIN orgs o ON o.disabled = $1 WHERE s.user_id = u.id AND u.org_id = o.id AND s.created_at < $2", sql)
+	assert.Equal(t, []interface{}{true, "2024-01-01"}, args)
+}
+
+func TestDeleteBuilderJoinSelect(t *testing.T) {
+	expired := Select("user_id").From("subscriptions").Where("ends_at < ?", "2024-01-01")
+
+	sql, args, err := Delete("sessions s").
+		Using("users u").
+		JoinSelect(expired, "e", Expr("e.user_id = u.id")).
+		Where("s.user_id = u.id").
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM sessions s USING users u JOIN (SELECT user_id FROM subscriptions WHERE ends_at < $1) AS e ON e.user_id = u.id WHERE s.user_id = u.id", sql)
+	assert.Equal(t, []interface{}{"2024-01-01"}, args)
+
+	sql, _, err = Delete("sessions s").
+		JoinSelect(expired, "e", Expr("e.user_id = s.user_id")).
+		Dialect(MySql).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE s FROM sessions s JOIN (SELECT user_id FROM subscriptions WHERE ends_at < ?) AS e ON e.user_id = s.user_id", sql)
+}
@@ -0,0 +1,702 @@
+package squirrelly
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Dialect is the interface that describes the syntax differences between databases.
+//
+// Builders use a Dialect set with their Dialect method, or inherited from a
+// StatementBuilderType or Db. Builders without a dialect render the
+// Postgres/sqlite flavoured SQL they always have, with the placeholder format
+// set by their PlaceholderFormat method.
+type Dialect interface {
+	// Name returns the name of the dialect, for use in error messages.
+	Name() string
+
+	// PlaceholderFormat returns the placeholder format used by the database.
+	PlaceholderFormat() PlaceholderFormat
+
+	// QuoteIdent quotes a single identifier, such as a column or table name.
+	// Builders render the table and column names they're given as written,
+	// since they may be expressions, so it's only used for names that
+	// squirrelly writes itself: the aliases of ColumnsFor, and Col.
+	QuoteIdent(ident string) string
+
+	// LimitOffset renders the clause limiting the rows returned by a select
+	// statement. Either limit or offset may be empty, and an empty string is
+	// returned when both are. hasOrderBy reports whether the statement has an
+	// ORDER BY clause.
+	LimitOffset(limit, offset string, hasOrderBy bool) string
+
+	// UpdateLimit renders the clause limiting the rows changed by an update or
+	// delete statement, or an empty string if neither limit nor offset is
+	// set. If top is true the clause is rendered SQL Server style, straight
+	// after the UPDATE or DELETE keyword. Otherwise it's rendered after the
+	// statement's ORDER BY clause.
+	UpdateLimit(limit, offset string) (clause string, top bool, err error)
+
+	// Upsert renders the clause that turns an insert into an upsert, which is
+	// rendered after the insert's values. It may be empty if the upsert is
+	// handled entirely by UpsertOptions.
+	Upsert(upsert Upsert) (string, []interface{}, error)
+
+	// UpsertOptions returns the options added after the INSERT keyword of an
+	// upsert, such as MySQL's IGNORE.
+	UpsertOptions(upsert Upsert) []string
+
+	// Returning renders the clause returning columns from an insert, update or
+	// delete statement. If output is true the clause is rendered SQL Server
+	// style, before the statement's values or WHERE clause, reading values from
+	// pseudoTable (INSERTED or DELETED). Otherwise it's rendered at the end of
+	// the statement, before any suffixes.
+	Returning(columns []string, pseudoTable string) (clause string, output bool, err error)
+
+	// RowLock renders the row locking clause of a select statement, which is
+	// rendered after its LIMIT and OFFSET clauses. It may be empty if the
+	// database doesn't lock rows.
+	RowLock(lock RowLock) (string, error)
+
+	// MultiTable returns how an update or delete statement, as given by
+	// statement, lists the tables it reads from besides its target table.
+	MultiTable(statement StatementKind) (MultiTableStyle, error)
+
+	// RowValues reports whether the database compares row values, such as
+	// (a, b) > (?, ?). Tuple comparisons are expanded into ANDed and ORed
+	// column comparisons for databases that don't.
+	RowValues() bool
+
+	// ILike reports whether the database has an ILIKE operator. Case
+	// insensitive pattern matches are rendered as LOWER(x) LIKE LOWER(?) for
+	// databases that don't.
+	ILike() bool
+
+	// AggregateFilter reports whether the database restricts the rows of an
+	// aggregate with a FILTER (WHERE ...) clause. Filtered aggregates
+	// aggregate CASE WHEN ... THEN ... END expressions for databases that
+	// don't.
+	AggregateFilter() bool
+
+	// CompoundParens reports whether the database accepts parenthesised
+	// queries in compound selects, which are needed to combine queries with
+	// their own ORDER BY or LIMIT clauses, and to make set operators apply
+	// from left to right. Compound selects of databases that don't, like
+	// sqlite, are rendered without parentheses.
+	CompoundParens() bool
+
+	// MergeAction returns an error if the database doesn't run merge
+	// statements, or doesn't support action, such as UPDATE or DO NOTHING, in
+	// their WHEN clauses.
+	MergeAction(action string) error
+
+	// Syntax returns the strings, quoted identifiers and comments of the
+	// database's SQL, which placeholders are never replaced inside.
+	Syntax() SqlSyntax
+}
+
+// Upsert describes how an insert handles conflicting rows, as set by
+// [InsertBuilder.OnConflict] and related methods.
+type Upsert struct {
+	// ConflictKeys are the columns whose conflict triggers the upsert.
+	ConflictKeys []string
+	// ConflictConstraint names the constraint whose conflict triggers the upsert, instead of ConflictKeys.
+	ConflictConstraint string
+	// ConflictWhere is the predicate of the partial unique index targeted by ConflictKeys.
+	ConflictWhere []Sqlizer
+	// UpdateColumns are the columns updated with the inserted values on conflict.
+	UpdateColumns []string
+	// UpdateSets are the columns updated with other values on conflict, rendered after UpdateColumns.
+	UpdateSets []UpsertSet
+	// UpdateWhere restricts the conflicting rows that are updated.
+	UpdateWhere []Sqlizer
+	// DoNothing is set when conflicting rows are skipped.
+	DoNothing bool
+	// Select is set when the inserted rows come from a select statement rather than VALUES.
+	Select bool
+}
+
+// RowLock describes the row locking clause of a select statement, as set by
+// [SelectBuilder.ForUpdate] and related methods.
+type RowLock struct {
+	// Strength is the strength of the lock: UPDATE, NO KEY UPDATE or SHARE.
+	Strength string
+	// Of are the tables whose rows are locked, or every table of the statement if empty.
+	Of []string
+	// Wait is NOWAIT or SKIP LOCKED, or empty to wait for locked rows to be released.
+	Wait string
+}
+
+// MultiTableStyle is how update and delete statements list the tables they read
+// from, as set by [UpdateBuilder.From], [DeleteBuilder.Using] and their Join
+// methods.
+type MultiTableStyle int
+
+const (
+	// MultiTableFrom lists the tables in a clause after the target table:
+	// UPDATE t SET ... FROM tables, and DELETE FROM t USING tables.
+	MultiTableFrom MultiTableStyle = iota
+
+	// MultiTableJoin lists the tables alongside the target table:
+	// UPDATE t, tables SET ..., and DELETE t FROM t, tables.
+	MultiTableJoin
+)
+
+// StatementKind is the kind of statement a Dialect renders part of, as passed
+// to [Dialect.MultiTable].
+type StatementKind string
+
+const (
+	// UpdateStatement is an UPDATE statement, built by an UpdateBuilder.
+	UpdateStatement StatementKind = "UPDATE"
+
+	// DeleteStatement is a DELETE statement, built by a DeleteBuilder.
+	DeleteStatement StatementKind = "DELETE"
+)
+
+// UpsertSet is a column updated by an upsert, as set by [InsertBuilder.UpdateSet].
+type UpsertSet struct {
+	Column string
+	// Value is the column's new value, either a Sqlizer or a value bound as an argument.
+	Value interface{}
+}
+
+var (
+	// Sqlite is a Dialect for sqlite.
+	Sqlite = sqliteDialect{}
+
+	// Postgres is a Dialect for PostgreSQL.
+	Postgres = postgresDialect{}
+
+	// MySql is a Dialect for MySQL and MariaDB.
+	MySql = mysqlDialect{}
+
+	// MySql8 is a Dialect for MySQL 8.0.19 and later, which refers to inserted
+	// values in upserts through a row alias rather than the deprecated VALUES()
+	// function.
+	MySql8 = mysqlDialect{rowAlias: "new"}
+
+	// SqlServer is a Dialect for Microsoft SQL Server.
+	SqlServer = sqlServerDialect{}
+)
+
+// defaultDialect renders the SQL builders produce when no dialect is set.
+type defaultDialect struct{}
+
+func (defaultDialect) Name() string {
+	return "default"
+}
+
+func (defaultDialect) PlaceholderFormat() PlaceholderFormat {
+	return Question
+}
+
+func (defaultDialect) QuoteIdent(ident string) string {
+	return quoteIdent(ident, `"`, `"`)
+}
+
+func (defaultDialect) LimitOffset(limit, offset string, hasOrderBy bool) string {
+	return limitOffset(limit, offset, "")
+}
+
+func (defaultDialect) UpdateLimit(limit, offset string) (string, bool, error) {
+	return limitOffset(limit, offset, ""), false, nil
+}
+
+func (d defaultDialect) Upsert(upsert Upsert) (string, []interface{}, error) {
+	return onConflictUpsert(d, upsert)
+}
+
+func (defaultDialect) UpsertOptions(upsert Upsert) []string {
+	return nil
+}
+
+func (defaultDialect) Returning(columns []string, pseudoTable string) (string, bool, error) {
+	return "RETURNING " + strings.Join(columns, ","), false, nil
+}
+
+func (defaultDialect) RowLock(lock RowLock) (string, error) {
+	return forRowLock(lock), nil
+}
+
+func (defaultDialect) MultiTable(statement StatementKind) (MultiTableStyle, error) {
+	return MultiTableFrom, nil
+}
+
+func (defaultDialect) RowValues() bool {
+	return true
+}
+
+func (defaultDialect) ILike() bool {
+	return true
+}
+
+func (defaultDialect) AggregateFilter() bool {
+	return true
+}
+
+func (defaultDialect) CompoundParens() bool {
+	return true
+}
+
+func (defaultDialect) MergeAction(action string) error {
+	return nil
+}
+
+func (defaultDialect) Syntax() SqlSyntax {
+	return SqlSyntax{}
+}
+
+type sqliteDialect struct {
+	defaultDialect
+}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) LimitOffset(limit, offset string, hasOrderBy bool) string {
+	// sqlite only accepts OFFSET after a LIMIT, where a negative limit means no limit
+	return limitOffset(limit, offset, "-1")
+}
+
+// UpdateLimit renders a LIMIT clause, which sqlite only accepts in update and
+// delete statements when built with SQLITE_ENABLE_UPDATE_DELETE_LIMIT.
+func (sqliteDialect) UpdateLimit(limit, offset string) (string, bool, error) {
+	return limitOffset(limit, offset, "-1"), false, nil
+}
+
+func (d sqliteDialect) Upsert(upsert Upsert) (string, []interface{}, error) {
+	if upsert.ConflictConstraint != "" {
+		return "", nil, errors.New("sqlite does not support ON CONFLICT ON CONSTRAINT, use OnConflict with the constraint's columns instead")
+	}
+	return onConflictUpsert(d, upsert)
+}
+
+// RowLock returns an empty clause, as sqlite locks the whole database rather
+// than rows.
+func (sqliteDialect) RowLock(lock RowLock) (string, error) {
+	return "", nil
+}
+
+func (sqliteDialect) MultiTable(statement StatementKind) (MultiTableStyle, error) {
+	if statement == DeleteStatement {
+		return 0, errors.New("sqlite does not support deleting with USING or joins, use a subquery in Where instead")
+	}
+	return MultiTableFrom, nil
+}
+
+func (sqliteDialect) ILike() bool {
+	return false
+}
+
+// CompoundParens returns false, as sqlite rejects parenthesised queries in
+// compound selects. It applies every set operator from left to right, with the
+// same precedence.
+func (sqliteDialect) CompoundParens() bool {
+	return false
+}
+
+func (sqliteDialect) MergeAction(action string) error {
+	return errors.New("sqlite does not support MERGE statements, use an upsert instead")
+}
+
+func (sqliteDialect) Syntax() SqlSyntax {
+	return SqlSyntax{BacktickQuotes: true, BracketQuotes: true}
+}
+
+type postgresDialect struct {
+	defaultDialect
+}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) PlaceholderFormat() PlaceholderFormat {
+	return Dollar
+}
+
+func (postgresDialect) UpdateLimit(limit, offset string) (string, bool, error) {
+	if limit == "" && offset == "" {
+		return "", false, nil
+	}
+	return "", false, errors.New("postgres does not support LIMIT or OFFSET in update and delete statements, use a subquery in Where instead")
+}
+
+func (postgresDialect) Syntax() SqlSyntax {
+	return SqlSyntax{DollarQuotes: true, JsonOperators: true}
+}
+
+type mysqlDialect struct {
+	// rowAlias names the inserted row in ON DUPLICATE KEY UPDATE clauses, if set
+	rowAlias string
+}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) PlaceholderFormat() PlaceholderFormat {
+	return Question
+}
+
+func (mysqlDialect) QuoteIdent(ident string) string {
+	return quoteIdent(ident, "`", "`")
+}
+
+func (mysqlDialect) LimitOffset(limit, offset string, hasOrderBy bool) string {
+	// MySQL only accepts OFFSET after a LIMIT, and documents the largest unsigned bigint as the way to skip it
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+func (mysqlDialect) UpdateLimit(limit, offset string) (string, bool, error) {
+	if offset != "" {
+		return "", false, errors.New("mysql does not support OFFSET in update and delete statements")
+	}
+	return limitOffset(limit, "", ""), false, nil
+}
+
+// Upsert renders an ON DUPLICATE KEY UPDATE clause. MySQL checks every unique
+// key of the table for conflicts, so the upsert's ConflictKeys and
+// ConflictConstraint aren't used.
+func (d mysqlDialect) Upsert(upsert Upsert) (string, []interface{}, error) {
+	if err := validateUpsert(upsert); err != nil {
+		return "", nil, err
+	}
+
+	if len(upsert.ConflictWhere) > 0 || len(upsert.UpdateWhere) > 0 {
+		return "", nil, errors.New("mysql does not support WHERE clauses in upserts")
+	}
+
+	// DoNothing is handled by INSERT IGNORE
+	if upsert.DoNothing {
+		return "", nil, nil
+	}
+
+	sql := &strings.Builder{}
+
+	// row aliases can't be used with INSERT ... SELECT, which still supports VALUES()
+	useAlias := d.rowAlias != "" && !upsert.Select
+	if useAlias {
+		sql.WriteString("AS ")
+		sql.WriteString(d.rowAlias)
+		sql.WriteString(" ")
+	}
+
+	sql.WriteString("ON DUPLICATE KEY UPDATE ")
+
+	sets := make([]string, len(upsert.UpdateColumns))
+	for idx, col := range upsert.UpdateColumns {
+		if useAlias {
+			sets[idx] = fmt.Sprintf("%[1]s = %[2]s.%[1]s", col, d.rowAlias)
+		} else {
+			sets[idx] = fmt.Sprintf("%[1]s = VALUES(%[1]s)", col)
+		}
+	}
+
+	args, err := appendUpsertSets(sql, d, sets, upsert.UpdateSets, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sql.String(), args, nil
+}
+
+func (mysqlDialect) UpsertOptions(upsert Upsert) []string {
+	if upsert.DoNothing {
+		return []string{"IGNORE"}
+	}
+	return nil
+}
+
+func (mysqlDialect) Returning(columns []string, pseudoTable string) (string, bool, error) {
+	return "", false, errors.New("mysql does not support RETURNING clauses")
+}
+
+func (mysqlDialect) RowLock(lock RowLock) (string, error) {
+	if lock.Strength != "UPDATE" && lock.Strength != "SHARE" {
+		return "", fmt.Errorf("mysql does not support FOR %s row locks", lock.Strength)
+	}
+	return forRowLock(lock), nil
+}
+
+func (mysqlDialect) MultiTable(statement StatementKind) (MultiTableStyle, error) {
+	return MultiTableJoin, nil
+}
+
+func (mysqlDialect) RowValues() bool {
+	return true
+}
+
+func (mysqlDialect) ILike() bool {
+	return false
+}
+
+func (mysqlDialect) AggregateFilter() bool {
+	return false
+}
+
+func (mysqlDialect) CompoundParens() bool {
+	return true
+}
+
+func (mysqlDialect) MergeAction(action string) error {
+	return errors.New("mysql does not support MERGE statements, use an upsert instead")
+}
+
+func (mysqlDialect) Syntax() SqlSyntax {
+	return SqlSyntax{BackslashEscapes: true, BacktickQuotes: true, HashComments: true}
+}
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) Name() string {
+	return "sqlserver"
+}
+
+func (sqlServerDialect) PlaceholderFormat() PlaceholderFormat {
+	return AtP
+}
+
+func (sqlServerDialect) QuoteIdent(ident string) string {
+	return quoteIdent(ident, "[", "]")
+}
+
+func (sqlServerDialect) LimitOffset(limit, offset string, hasOrderBy bool) string {
+	if limit == "" && offset == "" {
+		return ""
+	}
+
+	sql := &strings.Builder{}
+
+	// OFFSET ... FETCH is part of the ORDER BY clause, so it needs one even if the order doesn't matter
+	if !hasOrderBy {
+		sql.WriteString("ORDER BY (SELECT NULL) ")
+	}
+
+	if offset == "" {
+		offset = "0"
+	}
+	fmt.Fprintf(sql, "OFFSET %s ROWS", offset)
+
+	if limit != "" {
+		fmt.Fprintf(sql, " FETCH NEXT %s ROWS ONLY", limit)
+	}
+
+	return sql.String()
+}
+
+// UpdateLimit renders a TOP clause, which SQL Server applies to arbitrary rows.
+func (sqlServerDialect) UpdateLimit(limit, offset string) (string, bool, error) {
+	if offset != "" {
+		return "", false, errors.New("sqlserver does not support OFFSET in update and delete statements")
+	}
+	if limit == "" {
+		return "", false, nil
+	}
+	return fmt.Sprintf("TOP (%s)", limit), true, nil
+}
+
+func (sqlServerDialect) Upsert(upsert Upsert) (string, []interface{}, error) {
+	return "", nil, errors.New("sqlserver does not support upserts, use a MERGE statement instead")
+}
+
+func (sqlServerDialect) UpsertOptions(upsert Upsert) []string {
+	return nil
+}
+
+func (sqlServerDialect) Returning(columns []string, pseudoTable string) (string, bool, error) {
+	outputs := make([]string, len(columns))
+	for idx, col := range columns {
+		outputs[idx] = pseudoTable + "." + col
+	}
+
+	return "OUTPUT " + strings.Join(outputs, ","), true, nil
+}
+
+func (sqlServerDialect) RowLock(lock RowLock) (string, error) {
+	return "", errors.New("sqlserver does not support row locking clauses, use table hints instead")
+}
+
+// MultiTable lists the tables of updates in a FROM clause, and those of deletes
+// alongside the target table, as SQL Server has no USING clause.
+func (sqlServerDialect) MultiTable(statement StatementKind) (MultiTableStyle, error) {
+	if statement == DeleteStatement {
+		return MultiTableJoin, nil
+	}
+	return MultiTableFrom, nil
+}
+
+func (sqlServerDialect) RowValues() bool {
+	return false
+}
+
+func (sqlServerDialect) ILike() bool {
+	return false
+}
+
+func (sqlServerDialect) AggregateFilter() bool {
+	return false
+}
+
+func (sqlServerDialect) CompoundParens() bool {
+	return true
+}
+
+func (sqlServerDialect) MergeAction(action string) error {
+	if action == "DO NOTHING" {
+		return errors.New("sqlserver does not support DO NOTHING in MERGE statements, exclude the rows with the WHEN conditions instead")
+	}
+	return nil
+}
+
+func (sqlServerDialect) Syntax() SqlSyntax {
+	return SqlSyntax{BracketQuotes: true}
+}
+
+// dialectOrDefault returns d, or the default dialect if d isn't set.
+func dialectOrDefault(d Dialect) Dialect {
+	if d != nil {
+		return d
+	}
+	return defaultDialect{}
+}
+
+// quoteIdent wraps ident in the open and close quotes, doubling any close quotes inside it.
+func quoteIdent(ident, open, close string) string {
+	return open + strings.ReplaceAll(ident, close, close+close) + close
+}
+
+// limitOffset renders a LIMIT/OFFSET clause, using noLimit as the limit when only an offset is set.
+func limitOffset(limit, offset, noLimit string) string {
+	if limit == "" && offset != "" && noLimit != "" {
+		limit = noLimit
+	}
+
+	parts := []string{}
+	if limit != "" {
+		parts = append(parts, "LIMIT "+limit)
+	}
+	if offset != "" {
+		parts = append(parts, "OFFSET "+offset)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// forRowLock renders a Postgres and MySQL style FOR UPDATE clause.
+func forRowLock(lock RowLock) string {
+	sql := "FOR " + lock.Strength
+	if len(lock.Of) > 0 {
+		sql += " OF " + strings.Join(lock.Of, ", ")
+	}
+	if lock.Wait != "" {
+		sql += " " + lock.Wait
+	}
+	return sql
+}
+
+func validateUpsert(upsert Upsert) error {
+	updates := len(upsert.UpdateColumns) > 0 || len(upsert.UpdateSets) > 0
+
+	if upsert.DoNothing && updates {
+		return errors.New("insert statements with OnConflict can't use both UpdateColumns and DoNothing")
+	}
+
+	if !upsert.DoNothing && !updates {
+		return errors.New("insert statements with OnConflict set must have at least one column to be updated")
+	}
+
+	if upsert.ConflictConstraint != "" && len(upsert.ConflictKeys) > 0 {
+		return errors.New("insert statements can't use both OnConflict keys and OnConflictConstraint")
+	}
+
+	if len(upsert.ConflictWhere) > 0 && len(upsert.ConflictKeys) == 0 {
+		return errors.New("insert statements with OnConflictWhere must set the conflicting keys with OnConflict")
+	}
+
+	if upsert.DoNothing && len(upsert.UpdateWhere) > 0 {
+		return errors.New("insert statements with UpdateWhere can't use DoNothing")
+	}
+
+	return nil
+}
+
+// onConflictUpsert renders Postgres and sqlite style ON CONFLICT clauses for
+// dialect d.
+func onConflictUpsert(d Dialect, upsert Upsert) (string, []interface{}, error) {
+	if err := validateUpsert(upsert); err != nil {
+		return "", nil, err
+	}
+
+	if !upsert.DoNothing && len(upsert.ConflictKeys) == 0 && upsert.ConflictConstraint == "" {
+		return "", nil, errors.New("insert statements with UpdateColumns must set the conflicting keys with OnConflict")
+	}
+
+	sql := &strings.Builder{}
+	sql.WriteString("ON CONFLICT")
+
+	var args []interface{}
+	var err error
+
+	if upsert.ConflictConstraint != "" {
+		sql.WriteString(" ON CONSTRAINT ")
+		sql.WriteString(upsert.ConflictConstraint)
+	} else if len(upsert.ConflictKeys) > 0 {
+		sql.WriteString(" (")
+		sql.WriteString(strings.Join(upsert.ConflictKeys, ","))
+		sql.WriteString(")")
+
+		if len(upsert.ConflictWhere) > 0 {
+			sql.WriteString(" WHERE ")
+			args, err = appendToSql(upsert.ConflictWhere, d, sql, " AND ", args)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	if upsert.DoNothing {
+		sql.WriteString(" DO NOTHING")
+		return sql.String(), args, nil
+	}
+
+	sql.WriteString(" DO UPDATE SET ")
+
+	sets := make([]string, len(upsert.UpdateColumns))
+	for idx, col := range upsert.UpdateColumns {
+		sets[idx] = fmt.Sprintf("%[1]s = EXCLUDED.%[1]s", col)
+	}
+
+	args, err = appendUpsertSets(sql, d, sets, upsert.UpdateSets, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(upsert.UpdateWhere) > 0 {
+		sql.WriteString(" WHERE ")
+		args, err = appendToSql(upsert.UpdateWhere, d, sql, " AND ", args)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return sql.String(), args, nil
+}
+
+// appendUpsertSets writes the comma separated assignments of an upsert, the already rendered sets followed by updateSets.
+func appendUpsertSets(sql *strings.Builder, d Dialect, sets []string, updateSets []UpsertSet, args []interface{}) ([]interface{}, error) {
+	for _, set := range updateSets {
+		valSql, vargs, err := operandSql(set.Value, d)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, vargs...)
+		sets = append(sets, fmt.Sprintf("%s = %s", set.Column, valSql))
+	}
+
+	sql.WriteString(strings.Join(sets, ", "))
+	return args, nil
+}
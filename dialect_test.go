@@ -0,0 +1,565 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dialectGolden struct {
+	name    string
+	builder Sqlizer
+	sql     string
+	args    []interface{}
+	err     bool
+}
+
+func runDialectGoldens(t *testing.T, goldens []dialectGolden) {
+	for _, golden := range goldens {
+		t.Run(golden.name, func(t *testing.T) {
+			sql, args, err := golden.builder.ToSql()
+			if golden.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, golden.sql, sql)
+			assert.Equal(t, golden.args, args)
+		})
+	}
+}
+
+func TestDialectQuoteIdent(t *testing.T) {
+	assert.Equal(t, `"a""b"`, Sqlite.QuoteIdent(`a"b`))
+	assert.Equal(t, `"a""b"`, Postgres.QuoteIdent(`a"b`))
+	assert.Equal(t, "`a``b`", MySql.QuoteIdent("a`b"))
+	assert.Equal(t, "[a]]b]", SqlServer.QuoteIdent("a]b"))
+}
+
+func TestDialectDefault(t *testing.T) {
+	runDialectGoldens(t, []dialectGolden{
+		{
+			name:    "limit offset",
+			builder: Select("a").From("t").Where("b = ?", 1).Offset(10),
+			sql:     "SELECT a FROM t WHERE b = ? OFFSET 10",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "update limit",
+			builder: Update("t").Set("a", 1).OrderBy("b").Limit(5).Offset(10),
+			sql:     "UPDATE t SET a = ? ORDER BY b LIMIT 5 OFFSET 10",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "upsert",
+			builder: Insert("t").Columns("a", "b").Values(1, 2).OnConflict("a").UpdateColumns("b").Returning("id"),
+			sql:     "INSERT INTO t (a,b) VALUES (?,?) ON CONFLICT (a) DO UPDATE SET b = EXCLUDED.b RETURNING id",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "upsert do nothing without conflict keys",
+			builder: Insert("t").Columns("a").Values(1).DoNothing(),
+			sql:     "INSERT INTO t (a) VALUES (?) ON CONFLICT DO NOTHING",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "upsert update without conflict keys",
+			builder: Insert("t").Columns("a").Values(1).UpdateColumns("a"),
+			err:     true,
+		},
+		{
+			name:    "update returning",
+			builder: Update("t").Set("a", 1).Where("b = ?", 2).Returning("a", "b").Suffix("-- end"),
+			sql:     "UPDATE t SET a = ? WHERE b = ? RETURNING a,b -- end",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "delete returning",
+			builder: Delete("t").Where("b = ?", 2).Returning("a"),
+			sql:     "DELETE FROM t WHERE b = ? RETURNING a",
+			args:    []interface{}{2},
+		},
+	})
+}
+
+func TestDialectSqlite(t *testing.T) {
+	sb := StatementBuilder.Dialect(Sqlite)
+
+	runDialectGoldens(t, []dialectGolden{
+		{
+			name:    "limit offset",
+			builder: sb.Select("a").From("t").Limit(5).Offset(10),
+			sql:     "SELECT a FROM t LIMIT 5 OFFSET 10",
+		},
+		{
+			name:    "compound chain",
+			builder: sb.Select("a").From("x").Union(Select("a").From("y")).Intersect(Select("a").From("z")).OrderBy("a").Limit(1),
+			sql:     "SELECT a FROM x UNION SELECT a FROM y INTERSECT SELECT a FROM z ORDER BY a LIMIT 1",
+		},
+		{
+			name:    "compound of limited query",
+			builder: sb.Union(Select("a").From("x").Limit(1), Select("a").From("y")),
+			err:     true,
+		},
+		{
+			name:    "compound of ordered query",
+			builder: sb.Union(Select("a").From("x"), Select("a").From("y").OrderBy("a")),
+			err:     true,
+		},
+		{
+			name:    "nested compound",
+			builder: sb.Union(Select("a").From("x"), Intersect(Select("a").From("y"), Select("a").From("z"))),
+			err:     true,
+		},
+		{
+			name:    "delete limit",
+			builder: sb.Delete("t").OrderBy("b").Offset(10),
+			sql:     "DELETE FROM t ORDER BY b LIMIT -1 OFFSET 10",
+		},
+		{
+			name:    "offset only",
+			builder: sb.Select("a").From("t").Where("b = ?", 1).Offset(10),
+			sql:     "SELECT a FROM t WHERE b = ? LIMIT -1 OFFSET 10",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "upsert",
+			builder: sb.Insert("t").Columns("a", "b").Values(1, 2).OnConflict("a").UpdateColumns("b").Returning("id"),
+			sql:     "INSERT INTO t (a,b) VALUES (?,?) ON CONFLICT (a) DO UPDATE SET b = EXCLUDED.b RETURNING id",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "upsert do nothing",
+			builder: sb.Insert("t").Columns("a").Values(1).OnConflict("a").DoNothing(),
+			sql:     "INSERT INTO t (a) VALUES (?) ON CONFLICT (a) DO NOTHING",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "row lock",
+			builder: sb.Select("a").From("t").Limit(1).ForUpdate().SkipLocked(),
+			sql:     "SELECT a FROM t LIMIT 1",
+		},
+		{
+			name:    "upsert on constraint",
+			builder: sb.Insert("t").Columns("a").Values(1).OnConflictConstraint("t_pkey").DoNothing(),
+			err:     true,
+		},
+		{
+			name:    "columns for",
+			builder: sb.Select().ColumnsFor(struct{ A int }{}, "x"),
+			sql:     `SELECT x.A AS "x.A"`,
+		},
+		{
+			name:    "update from join",
+			builder: sb.Update("t").Set("a", Expr("u.a")).From("u").Join("v ON v.id = u.v_id").Where("t.id = u.t_id"),
+			sql:     "UPDATE t SET a = u.a FROM u JOIN v ON v.id = u.v_id WHERE t.id = u.t_id",
+		},
+		{
+			name:    "delete using",
+			builder: sb.Delete("t").Using("u").Where("t.id = u.t_id"),
+			err:     true,
+		},
+	})
+}
+
+func TestDialectPostgres(t *testing.T) {
+	sb := StatementBuilder.Dialect(Postgres)
+
+	runDialectGoldens(t, []dialectGolden{
+		{
+			name:    "limit offset",
+			builder: sb.Select("a").From("t").Where("b = ?", 1).Limit(5).Offset(10),
+			sql:     "SELECT a FROM t WHERE b = $1 LIMIT 5 OFFSET 10",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "update limit",
+			builder: sb.Update("t").Set("a", 1).Limit(5),
+			err:     true,
+		},
+		{
+			name:    "delete offset",
+			builder: sb.Delete("t").Offset(5),
+			err:     true,
+		},
+		{
+			name:    "offset only",
+			builder: sb.Select("a").From("t").Offset(10),
+			sql:     "SELECT a FROM t OFFSET 10",
+		},
+		{
+			name:    "upsert",
+			builder: sb.Insert("t").Columns("a", "b", "c").Values(1, 2, 3).OnConflict("a").UpdateColumns("b", "c").Returning("id"),
+			sql:     "INSERT INTO t (a,b,c) VALUES ($1,$2,$3) ON CONFLICT (a) DO UPDATE SET b = EXCLUDED.b, c = EXCLUDED.c RETURNING id",
+			args:    []interface{}{1, 2, 3},
+		},
+		{
+			name:    "row lock",
+			builder: sb.Select("a").From("t").Where("b = ?", 1).Limit(1).ForNoKeyUpdate().Of("t").SkipLocked(),
+			sql:     "SELECT a FROM t WHERE b = $1 LIMIT 1 FOR NO KEY UPDATE OF t SKIP LOCKED",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "update returning",
+			builder: sb.Update("t").Set("a", 1).Where("b = ?", 2).Returning("a"),
+			sql:     "UPDATE t SET a = $1 WHERE b = $2 RETURNING a",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "delete returning",
+			builder: sb.Delete("t").Where("b = ?", 2).Returning("a"),
+			sql:     "DELETE FROM t WHERE b = $1 RETURNING a",
+			args:    []interface{}{2},
+		},
+		{
+			name:    "update from join",
+			builder: sb.Update("t").Set("a", Expr("u.a")).From("u").From("w").JoinOn("v", Expr("v.id = u.v_id AND v.b = ?", 1)).Where("t.id = u.t_id").Where("w.c = ?", 2),
+			sql:     "UPDATE t SET a = u.a FROM u, w JOIN v ON v.id = u.v_id AND v.b = $1 WHERE t.id = u.t_id AND w.c = $2",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "update join without from",
+			builder: sb.Update("t").Set("a", 1).Join("u ON u.id = t.u_id"),
+			err:     true,
+		},
+		{
+			name:    "delete using join",
+			builder: sb.Delete("t").Using("u").LeftJoin("v ON v.id = u.v_id").Where("t.id = u.t_id AND v.id IS NULL").Returning("t.id"),
+			sql:     "DELETE FROM t USING u LEFT JOIN v ON v.id = u.v_id WHERE t.id = u.t_id AND v.id IS NULL RETURNING t.id",
+		},
+		{
+			name:    "delete join without using",
+			builder: sb.Delete("t").Join("u ON u.id = t.u_id"),
+			err:     true,
+		},
+	})
+}
+
+func TestDialectMySql(t *testing.T) {
+	sb := StatementBuilder.Dialect(MySql)
+
+	runDialectGoldens(t, []dialectGolden{
+		{
+			name:    "limit offset",
+			builder: sb.Select("a").From("t").Limit(5).Offset(10),
+			sql:     "SELECT a FROM t LIMIT 5 OFFSET 10",
+		},
+		{
+			name:    "update limit",
+			builder: sb.Update("t").Set("a", 1).OrderBy("b").Limit(5),
+			sql:     "UPDATE t SET a = ? ORDER BY b LIMIT 5",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "delete offset",
+			builder: sb.Delete("t").Limit(5).Offset(10),
+			err:     true,
+		},
+		{
+			name:    "offset only",
+			builder: sb.Select("a").From("t").Offset(10),
+			sql:     "SELECT a FROM t LIMIT 18446744073709551615 OFFSET 10",
+		},
+		{
+			name:    "upsert",
+			builder: sb.Insert("t").Columns("a", "b", "c").Values(1, 2, 3).OnConflict("a").UpdateColumns("b", "c"),
+			sql:     "INSERT INTO t (a,b,c) VALUES (?,?,?) ON DUPLICATE KEY UPDATE b = VALUES(b), c = VALUES(c)",
+			args:    []interface{}{1, 2, 3},
+		},
+		{
+			name:    "upsert without conflict keys",
+			builder: sb.Insert("t").Columns("a", "b").Values(1, 2).UpdateColumns("b"),
+			sql:     "INSERT INTO t (a,b) VALUES (?,?) ON DUPLICATE KEY UPDATE b = VALUES(b)",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "upsert do nothing",
+			builder: sb.Insert("t").Columns("a").Values(1).OnConflict("a").DoNothing(),
+			sql:     "INSERT IGNORE INTO t (a) VALUES (?)",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "upsert set expressions",
+			builder: sb.Insert("t").Columns("a", "b").Values(1, 2).UpdateColumns("a").UpdateSet("b", Expr("b + VALUES(b)")),
+			sql:     "INSERT INTO t (a,b) VALUES (?,?) ON DUPLICATE KEY UPDATE a = VALUES(a), b = b + VALUES(b)",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "upsert update where",
+			builder: sb.Insert("t").Columns("a").Values(1).UpdateColumns("a").UpdateWhere("a > ?", 0),
+			err:     true,
+		},
+		{
+			name:    "upsert do nothing with ignore option",
+			builder: sb.Insert("t").Options("IGNORE").Columns("a").Values(1).DoNothing(),
+			sql:     "INSERT IGNORE INTO t (a) VALUES (?)",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "upsert both update and do nothing",
+			builder: sb.Insert("t").Columns("a").Values(1).UpdateColumns("a").DoNothing(),
+			err:     true,
+		},
+		{
+			name:    "insert returning",
+			builder: sb.Insert("t").Columns("a").Values(1).Returning("id"),
+			err:     true,
+		},
+		{
+			name:    "row lock",
+			builder: sb.Select("a").From("t").Offset(1).ForShare().NoWait(),
+			sql:     "SELECT a FROM t LIMIT 18446744073709551615 OFFSET 1 FOR SHARE NOWAIT",
+		},
+		{
+			name:    "no key update row lock",
+			builder: sb.Select("a").From("t").ForNoKeyUpdate(),
+			err:     true,
+		},
+		{
+			name:    "columns for",
+			builder: sb.Select().ColumnsFor(struct{ A int }{}, "x"),
+			sql:     "SELECT x.A AS `x.A`",
+		},
+		{
+			name:    "update join",
+			builder: sb.Update("t").Join("u ON u.id = t.u_id").Set("t.a", Expr("u.a")).Where("u.b = ?", 1),
+			sql:     "UPDATE t JOIN u ON u.id = t.u_id SET t.a = u.a WHERE u.b = ?",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "update from",
+			builder: sb.Update("t").From("u").JoinOn("v", Expr("v.id = u.v_id")).Set("t.a", 1).Where("t.id = u.t_id"),
+			sql:     "UPDATE t, u JOIN v ON v.id = u.v_id SET t.a = ? WHERE t.id = u.t_id",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "delete join",
+			builder: sb.Delete("orders o").LeftJoin("users u ON u.id = o.user_id").Where("u.id IS NULL"),
+			sql:     "DELETE o FROM orders o LEFT JOIN users u ON u.id = o.user_id WHERE u.id IS NULL",
+		},
+		{
+			name:    "delete using",
+			builder: sb.Delete("t").Using("u").Where("t.id = u.t_id"),
+			sql:     "DELETE t FROM t, u WHERE t.id = u.t_id",
+		},
+	})
+}
+
+func TestDialectMySql8(t *testing.T) {
+	sb := StatementBuilder.Dialect(MySql8)
+
+	runDialectGoldens(t, []dialectGolden{
+		{
+			name:    "upsert",
+			builder: sb.Insert("t").Columns("a", "b", "c").Values(1, 2, 3).Values(4, 5, 6).OnConflict("a").UpdateColumns("b", "c"),
+			sql:     "INSERT INTO t (a,b,c) VALUES (?,?,?),(?,?,?) AS new ON DUPLICATE KEY UPDATE b = new.b, c = new.c",
+			args:    []interface{}{1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:    "upsert from select",
+			builder: sb.Insert("t").Columns("a", "b").Select(Select("a", "b").From("u")).UpdateColumns("b"),
+			sql:     "INSERT INTO t (a,b) SELECT a, b FROM u ON DUPLICATE KEY UPDATE b = VALUES(b)",
+		},
+		{
+			name:    "upsert do nothing",
+			builder: sb.Insert("t").Columns("a").Values(1).DoNothing(),
+			sql:     "INSERT IGNORE INTO t (a) VALUES (?)",
+			args:    []interface{}{1},
+		},
+	})
+}
+
+func TestDialectSqlServer(t *testing.T) {
+	sb := StatementBuilder.Dialect(SqlServer)
+
+	runDialectGoldens(t, []dialectGolden{
+		{
+			name:    "limit offset",
+			builder: sb.Select("a").From("t").Where("b = ?", 1).OrderBy("a").Limit(5).Offset(10),
+			sql:     "SELECT a FROM t WHERE b = @p1 ORDER BY a OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY",
+			args:    []interface{}{1},
+		},
+		{
+			name:    "update limit",
+			builder: sb.Update("t").Set("a", 1).Where("b = ?", 2).Limit(5),
+			sql:     "UPDATE TOP (5) t SET a = @p1 WHERE b = @p2",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "delete limit",
+			builder: sb.Delete("t").Where("b = ?", 2).Limit(5),
+			sql:     "DELETE TOP (5) FROM t WHERE b = @p1",
+			args:    []interface{}{2},
+		},
+		{
+			name:    "delete join limit",
+			builder: sb.Delete("t").JoinOn("u", Expr("u.id = t.u_id")).Limit(5),
+			sql:     "DELETE TOP (5) t FROM t JOIN u ON u.id = t.u_id",
+		},
+		{
+			name:    "update offset",
+			builder: sb.Update("t").Set("a", 1).Offset(5),
+			err:     true,
+		},
+		{
+			name:    "limit without order by",
+			builder: sb.Select("a").From("t").Limit(5),
+			sql:     "SELECT a FROM t ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY",
+		},
+		{
+			name:    "offset only",
+			builder: sb.Select("a").From("t").OrderBy("a").Offset(10),
+			sql:     "SELECT a FROM t ORDER BY a OFFSET 10 ROWS",
+		},
+		{
+			name:    "insert output",
+			builder: sb.Insert("t").Columns("a", "b").Values(1, 2).Returning("id", "a"),
+			sql:     "INSERT INTO t (a,b) OUTPUT INSERTED.id,INSERTED.a VALUES (@p1,@p2)",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "insert select output",
+			builder: sb.Insert("t").Columns("a").Select(Select("a").From("u")).Returning("id"),
+			sql:     "INSERT INTO t (a) OUTPUT INSERTED.id SELECT a FROM u",
+		},
+		{
+			name:    "update output",
+			builder: sb.Update("t").Set("a", 1).Where("b = ?", 2).Returning("a"),
+			sql:     "UPDATE t SET a = @p1 OUTPUT INSERTED.a WHERE b = @p2",
+			args:    []interface{}{1, 2},
+		},
+		{
+			name:    "delete output",
+			builder: sb.Delete("t").Where("b = ?", 2).Returning("a"),
+			sql:     "DELETE FROM t OUTPUT DELETED.a WHERE b = @p1",
+			args:    []interface{}{2},
+		},
+		{
+			name:    "upsert",
+			builder: sb.Insert("t").Columns("a").Values(1).OnConflict("a").UpdateColumns("a"),
+			err:     true,
+		},
+		{
+			name:    "row lock",
+			builder: sb.Select("a").From("t").ForUpdate(),
+			err:     true,
+		},
+		{
+			name:    "columns for",
+			builder: sb.Select().ColumnsFor(struct{ A int }{}, "x"),
+			sql:     "SELECT x.A AS [x.A]",
+		},
+		{
+			name:    "update from join",
+			builder: sb.Update("t").Set("a", Expr("u.a")).From("u").Join("v ON v.id = u.v_id").Where("t.id = u.t_id").Returning("a"),
+			sql:     "UPDATE t SET a = u.a OUTPUT INSERTED.a FROM u JOIN v ON v.id = u.v_id WHERE t.id = u.t_id",
+		},
+		{
+			name:    "delete join",
+			builder: sb.Delete("t").JoinOn("u", Expr("u.id = t.u_id")).Where("u.b = ?", 1).Returning("a"),
+			sql:     "DELETE t OUTPUT DELETED.a FROM t JOIN u ON u.id = t.u_id WHERE u.b = @p1",
+			args:    []interface{}{1},
+		},
+	})
+}
+
+func TestBuilderDialectOverridesPlaceholderFormat(t *testing.T) {
+	sql, _, err := Select("a").PlaceholderFormat(Dollar).Dialect(SqlServer).Where("b = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a WHERE b = @p1", sql)
+
+	sql, _, err = Select("a").Dialect(SqlServer).PlaceholderFormat(Question).Where("b = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a WHERE b = ?", sql)
+}
+
+func TestBuilderDialectNil(t *testing.T) {
+	sql, _, err := Select("a").Dialect(SqlServer).Dialect(nil).Where("b = ?", 1).Limit(5).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a WHERE b = ? LIMIT 5", sql)
+
+	sql, _, err = StatementBuilder.Dialect(nil).Update("t").Set("a", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE t SET a = ?", sql)
+
+	for _, b := range []Sqlizer{
+		Insert("t").Values(1).Dialect(nil),
+		Delete("t").Dialect(nil),
+		Union(Select("a"), Select("b")).Dialect(nil),
+		Merge("t").Using("u").On("t.id = u.id").WhenMatched().ThenDelete().Dialect(nil),
+	} {
+		_, _, err = b.ToSql()
+		assert.NoError(t, err)
+	}
+}
+
+func TestDialectNestedQueries(t *testing.T) {
+	// nested builders without a dialect of their own use the statement's
+	sub := Select().Column(Col("u.x")).From("u").Where(Tuple("b", "c").Gt(1, 2)).Limit(1)
+	users := Select().ColumnsFor(struct{ Id int }{}, "v").From("v")
+
+	tests := []struct {
+		dialect  Dialect
+		sql      string
+		args     []interface{}
+		compound string
+	}{
+		{
+			dialect: Sqlite,
+			sql: `WITH w AS (SELECT "u"."x" FROM u WHERE (b, c) > (?, ?) LIMIT 1) ` +
+				`SELECT a FROM (SELECT v.Id AS "v.Id" FROM v) AS s ` +
+				`WHERE EXISTS (SELECT "u"."x" FROM u WHERE (b, c) > (?, ?) LIMIT 1)`,
+			args: []interface{}{1, 2, 1, 2},
+			// sqlite can't parenthesise the limited query
+		},
+		{
+			dialect: Postgres,
+			sql: `WITH w AS (SELECT "u"."x" FROM u WHERE (b, c) > ($1, $2) LIMIT 1) ` +
+				`SELECT a FROM (SELECT v.Id AS "v.Id" FROM v) AS s ` +
+				`WHERE EXISTS (SELECT "u"."x" FROM u WHERE (b, c) > ($3, $4) LIMIT 1)`,
+			args:     []interface{}{1, 2, 1, 2},
+			compound: `(SELECT "u"."x" FROM u WHERE (b, c) > ($1, $2) LIMIT 1) UNION SELECT x FROM y`,
+		},
+		{
+			dialect: MySql,
+			sql: "WITH w AS (SELECT `u`.`x` FROM u WHERE (b, c) > (?, ?) LIMIT 1) " +
+				"SELECT a FROM (SELECT v.Id AS `v.Id` FROM v) AS s " +
+				"WHERE EXISTS (SELECT `u`.`x` FROM u WHERE (b, c) > (?, ?) LIMIT 1)",
+			args:     []interface{}{1, 2, 1, 2},
+			compound: "(SELECT `u`.`x` FROM u WHERE (b, c) > (?, ?) LIMIT 1) UNION SELECT x FROM y",
+		},
+		{
+			dialect: SqlServer,
+			sql: "WITH w AS (SELECT [u].[x] FROM u WHERE (b > @p1 OR (b = @p2 AND c > @p3)) ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 1 ROWS ONLY) " +
+				"SELECT a FROM (SELECT v.Id AS [v.Id] FROM v) AS s " +
+				"WHERE EXISTS (SELECT [u].[x] FROM u WHERE (b > @p4 OR (b = @p5 AND c > @p6)) ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 1 ROWS ONLY)",
+			args:     []interface{}{1, 1, 2, 1, 1, 2},
+			compound: "(SELECT [u].[x] FROM u WHERE (b > @p1 OR (b = @p2 AND c > @p3)) ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 1 ROWS ONLY) UNION SELECT x FROM y",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dialect.Name(), func(t *testing.T) {
+			sb := StatementBuilder.Dialect(test.dialect)
+
+			sql, args, err := sb.Select("a").With("w", sub).FromSelect(users, "s").Where(Exists(sub)).ToSql()
+			assert.NoError(t, err)
+			assert.Equal(t, test.sql, sql)
+			assert.Equal(t, test.args, args)
+
+			sql, _, err = sb.Union(sub, Select("x").From("y")).ToSql()
+			if test.compound == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.compound, sql)
+		})
+	}
+
+	// a nested builder's own dialect is kept
+	sql, _, err := Select("a").Where(Exists(sub.Dialect(Postgres))).Dialect(SqlServer).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT a WHERE EXISTS (SELECT "u"."x" FROM u WHERE (b, c) > (@p1, @p2) LIMIT 1)`, sql)
+}
@@ -0,0 +1,85 @@
+package squirrelly
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Diff compares two structs of the same type using their `sq` tags, and returns the columns whose values differ, sorted by name.
+//
+// Values are compared the way a driver would see them: fields implementing [database/sql/driver.Valuer] are compared using the result of their Value method, pointers are dereferenced, and [time.Time] values are compared with [time.Time.Equal].
+//
+// Nested structs that aren't [database/sql/driver.Valuer]s are compared field by field, so only their changed columns are returned.
+func Diff(original, modified interface{}) ([]string, error) {
+	return diff(getMapper(), original, modified)
+}
+
+func diff(mapper *Mapper, original, modified interface{}) ([]string, error) {
+	origValue := reflect.Indirect(reflect.ValueOf(original))
+	modValue := reflect.Indirect(reflect.ValueOf(modified))
+
+	if origValue.Kind() != reflect.Struct || modValue.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("diff arguments must be structs, not %T and %T", original, modified)
+	}
+
+	if origValue.Type() != modValue.Type() {
+		return nil, fmt.Errorf("cannot diff values of different types %T and %T", original, modified)
+	}
+
+	typeMap := mapper.typeMap(origValue.Type())
+
+	columns := []string{}
+	for _, field := range columnFields(typeMap) {
+		column := field.Path
+		equal, err := diffValuesEqual(
+			fieldByIndexesNilable(origValue, field.Index),
+			fieldByIndexesNilable(modValue, field.Index),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compare column `%s`: %w", column, err)
+		}
+
+		if !equal {
+			columns = append(columns, column)
+		}
+	}
+
+	sort.Strings(columns)
+	return columns, nil
+}
+
+func diffValuesEqual(a, b reflect.Value) (bool, error) {
+	aValue, aErr := diffDriverValue(a)
+	bValue, bErr := diffDriverValue(b)
+
+	// values that can't be converted into driver values fall back to a deep comparison
+	if aErr != nil || bErr != nil {
+		if !a.IsValid() || !b.IsValid() {
+			return a.IsValid() == b.IsValid(), nil
+		}
+		return reflect.DeepEqual(a.Interface(), b.Interface()), nil
+	}
+
+	switch av := aValue.(type) {
+	case time.Time:
+		bv, ok := bValue.(time.Time)
+		return ok && av.Equal(bv), nil
+	case []byte:
+		bv, ok := bValue.([]byte)
+		return ok && (av == nil) == (bv == nil) && bytes.Equal(av, bv), nil
+	}
+
+	return aValue == bValue, nil
+}
+
+func diffDriverValue(v reflect.Value) (driver.Value, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(v.Interface())
+}
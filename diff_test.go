@@ -0,0 +1,108 @@
+package squirrelly
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type diffValuer struct {
+	raw string
+}
+
+func (v diffValuer) Value() (driver.Value, error) {
+	return strings.ToLower(v.raw), nil
+}
+
+type diffAddress struct {
+	City string `sq:"city"`
+	Zip  string `sq:"zip"`
+}
+
+type diffRecord struct {
+	Pk        int            `sq:"pk"`
+	Comment   string         `sq:"comment"`
+	Note      *string        `sq:"note"`
+	Score     sql.NullInt64  `sq:"score"`
+	Code      diffValuer     `sq:"code"`
+	UpdatedAt time.Time      `sq:"updated_at"`
+	Address   diffAddress    `sq:"address"`
+	Data      []byte         `sq:"data"`
+	Ignored   string         `sq:"-"`
+	Tags      map[string]int `sq:"tags"`
+}
+
+func TestDiff(t *testing.T) {
+	note := "note"
+	sameNote := "note"
+	now := time.Now()
+
+	original := diffRecord{
+		Pk:        1,
+		Comment:   "comment",
+		Note:      &note,
+		Score:     sql.NullInt64{Int64: 10, Valid: true},
+		Code:      diffValuer{raw: "abc"},
+		UpdatedAt: now,
+		Address:   diffAddress{City: "Portland", Zip: "97201"},
+		Data:      []byte("data"),
+		Tags:      map[string]int{"a": 1},
+	}
+
+	modified := original
+	modified.Note = &sameNote
+	modified.Code = diffValuer{raw: "ABC"}
+	modified.UpdatedAt = now.In(time.FixedZone("other", 3600))
+	modified.Data = []byte("data")
+	modified.Ignored = "ignored"
+	modified.Tags = map[string]int{"a": 1}
+
+	columns, err := Diff(original, &modified)
+	assert.NoError(t, err)
+	assert.Empty(t, columns)
+
+	modified.Comment = "updated comment"
+	modified.Note = nil
+	modified.Score = sql.NullInt64{}
+	modified.Code = diffValuer{raw: "xyz"}
+	modified.Address.Zip = "97202"
+	modified.Tags = map[string]int{"a": 2}
+
+	columns, err = Diff(&original, &modified)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"address.zip", "code", "comment", "note", "score", "tags"}, columns)
+}
+
+func TestDiffErrors(t *testing.T) {
+	_, err := Diff(1, 2)
+	assert.Error(t, err)
+
+	_, err = Diff(diffRecord{}, diffAddress{})
+	assert.Error(t, err)
+}
+
+func TestUpdateSetDiff(t *testing.T) {
+	original := diffRecord{Pk: 1, Comment: "comment", Score: sql.NullInt64{Int64: 1, Valid: true}}
+	modified := original
+	modified.Comment = "updated comment"
+
+	sql, args, err := Update("table").
+		SetDiff(&original, &modified).
+		Where(Eq{"pk": original.Pk}).
+		ToSql()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "UPDATE table SET comment = ? WHERE pk = ?", sql)
+	assert.Equal(t, []interface{}{"updated comment", 1}, args)
+
+	_, _, err = Update("table").SetDiff(original, original).ToSql()
+	assert.Error(t, err)
+
+	assert.Panics(t, func() {
+		Update("table").SetDiff(original, diffAddress{})
+	})
+}
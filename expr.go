@@ -29,7 +29,11 @@ func Expr(sql string, args ...interface{}) Sqlizer {
 	return expr{sql: sql, args: args}
 }
 
-func (e expr) ToSql() (sql string, args []interface{}, err error) {
+func (e expr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e expr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
 	simple := true
 	for _, arg := range e.args {
 		if _, ok := arg.(Sqlizer); ok {
@@ -62,7 +66,7 @@ func (e expr) ToSql() (sql string, args []interface{}, err error) {
 
 		if as, ok := ap[0].(Sqlizer); ok {
 			// sqlizer argument; expand it and append the result
-			isql, iargs, err = as.ToSql()
+			isql, iargs, err = nestedToSql(as, d)
 			buf.WriteString(sp[:i])
 			buf.WriteString(isql)
 			args = append(args, iargs...)
@@ -84,13 +88,17 @@ func (e expr) ToSql() (sql string, args []interface{}, err error) {
 
 type concatExpr []interface{}
 
-func (ce concatExpr) ToSql() (sql string, args []interface{}, err error) {
+func (ce concatExpr) ToSql() (string, []interface{}, error) {
+	return ce.toSqlDialect(nil)
+}
+
+func (ce concatExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
 	for _, part := range ce {
 		switch p := part.(type) {
 		case string:
 			sql += p
 		case Sqlizer:
-			pSql, pArgs, err := p.ToSql()
+			pSql, pArgs, err := nestedToSql(p, d)
 			if err != nil {
 				return "", nil, err
 			}
@@ -113,6 +121,44 @@ func ConcatExpr(parts ...interface{}) concatExpr {
 	return concatExpr(parts)
 }
 
+// Col is a column reference, for comparing columns with each other in Eq,
+// NotEq, Lt, Gt, Like and their variants, or setting a column to another
+// column's value with UpdateBuilder.Set. Unlike other values, which are bound to
+// placeholders, a Col renders as the identifier it holds.
+//
+// Ex:
+//
+//	.Where(Eq{"a.user_id": Col("b.id")}) == "a.user_id = b.id"
+//
+// Each part of a dotted name is quoted by the Dialect of the statement the Col
+// is rendered in, if it has one, so with Postgres the example above renders
+// a.user_id = "b"."id". ToSql renders the name as written.
+type Col string
+
+func (c Col) ToSql() (sql string, args []interface{}, err error) {
+	return string(c), nil, nil
+}
+
+func (c Col) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return quoteColumn(d, string(c)), nil, nil
+}
+
+// quoteColumn quotes each part of the dotted column name with dialect d, or
+// returns name unchanged if d isn't set.
+func quoteColumn(d Dialect, name string) string {
+	if d == nil {
+		return name
+	}
+
+	parts := strings.Split(name, ".")
+	for idx, part := range parts {
+		if part != "*" {
+			parts[idx] = d.QuoteIdent(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
 // aliasExpr helps to alias part of SQL query generated with underlying "expr"
 type aliasExpr struct {
 	expr  Sqlizer
@@ -128,8 +174,12 @@ func Alias(expr Sqlizer, alias string) aliasExpr {
 	return aliasExpr{expr, alias}
 }
 
-func (e aliasExpr) ToSql() (sql string, args []interface{}, err error) {
-	sql, args, err = e.expr.ToSql()
+func (e aliasExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e aliasExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	sql, args, err = nestedToSql(e.expr, d)
 	if err == nil {
 		sql = fmt.Sprintf("(%s) AS %s", sql, e.alias)
 	}
@@ -137,9 +187,16 @@ func (e aliasExpr) ToSql() (sql string, args []interface{}, err error) {
 }
 
 // Eq is syntactic sugar for use with Where/Having/Set methods.
+//
+// A subquery value, such as a SelectBuilder, renders "<key> IN (<subquery>)",
+// and any other Sqlizer value, such as a Col or Func, is rendered in place, as
+// in Lt and Like:
+//
+//	.Where(Eq{"author_id": Select("id").From("users").Where("active")})
+//	.Where(Eq{"updated_at": Func("now")}) == "updated_at = now()"
 type Eq map[string]interface{}
 
-func (eq Eq) toSQL(useNotOpr bool) (sql string, args []interface{}, err error) {
+func (eq Eq) toSQL(useNotOpr bool, d Dialect) (sql string, args []interface{}, err error) {
 	if len(eq) == 0 {
 		// Empty Sql{} evaluates to true.
 		sql = sqlTrue
@@ -166,6 +223,23 @@ func (eq Eq) toSQL(useNotOpr bool) (sql string, args []interface{}, err error) {
 		var expr string
 		val := eq[key]
 
+		if vs, ok := val.(Sqlizer); ok {
+			var vsql string
+			var vargs []interface{}
+			vsql, vargs, err = operandSql(vs, d)
+			if err != nil {
+				return
+			}
+
+			opr := equalOpr
+			if _, ok := vs.(Subquery); ok {
+				opr = inOpr
+			}
+			exprs = append(exprs, fmt.Sprintf("%s %s %s", key, opr, vsql))
+			args = append(args, vargs...)
+			continue
+		}
+
 		switch v := val.(type) {
 		case driver.Valuer:
 			if val, err = v.Value(); err != nil {
@@ -210,7 +284,11 @@ func (eq Eq) toSQL(useNotOpr bool) (sql string, args []interface{}, err error) {
 }
 
 func (eq Eq) ToSql() (sql string, args []interface{}, err error) {
-	return eq.toSQL(false)
+	return eq.toSQL(false, nil)
+}
+
+func (eq Eq) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return eq.toSQL(false, d)
 }
 
 // NotEq is syntactic sugar for use with Where/Having/Set methods.
@@ -220,7 +298,11 @@ func (eq Eq) ToSql() (sql string, args []interface{}, err error) {
 type NotEq Eq
 
 func (neq NotEq) ToSql() (sql string, args []interface{}, err error) {
-	return Eq(neq).toSQL(true)
+	return Eq(neq).toSQL(true, nil)
+}
+
+func (neq NotEq) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Eq(neq).toSQL(true, d)
 }
 
 // Like is syntactic sugar for use with LIKE conditions.
@@ -229,10 +311,25 @@ func (neq NotEq) ToSql() (sql string, args []interface{}, err error) {
 //	.Where(Like{"name": "%irrel"})
 type Like map[string]interface{}
 
-func (lk Like) toSql(opr string) (sql string, args []interface{}, err error) {
+func (lk Like) toSql(opr string, d Dialect) (sql string, args []interface{}, err error) {
 	var exprs []string
-	for key, val := range lk {
+
+	sortedKeys := getSortedKeys(lk)
+	for _, key := range sortedKeys {
 		expr := ""
+		val := lk[key]
+
+		if vs, ok := val.(Sqlizer); ok {
+			var vsql string
+			var vargs []interface{}
+			vsql, vargs, err = operandSql(vs, d)
+			if err != nil {
+				return
+			}
+			exprs = append(exprs, fmt.Sprintf("%s %s %s", key, opr, vsql))
+			args = append(args, vargs...)
+			continue
+		}
 
 		switch v := val.(type) {
 		case driver.Valuer:
@@ -260,7 +357,11 @@ func (lk Like) toSql(opr string) (sql string, args []interface{}, err error) {
 }
 
 func (lk Like) ToSql() (sql string, args []interface{}, err error) {
-	return lk.toSql("LIKE")
+	return lk.toSql("LIKE", nil)
+}
+
+func (lk Like) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return lk.toSql("LIKE", d)
 }
 
 // NotLike is syntactic sugar for use with LIKE conditions.
@@ -270,7 +371,11 @@ func (lk Like) ToSql() (sql string, args []interface{}, err error) {
 type NotLike Like
 
 func (nlk NotLike) ToSql() (sql string, args []interface{}, err error) {
-	return Like(nlk).toSql("NOT LIKE")
+	return Like(nlk).toSql("NOT LIKE", nil)
+}
+
+func (nlk NotLike) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Like(nlk).toSql("NOT LIKE", d)
 }
 
 // ILike is syntactic sugar for use with ILIKE conditions.
@@ -280,7 +385,11 @@ func (nlk NotLike) ToSql() (sql string, args []interface{}, err error) {
 type ILike Like
 
 func (ilk ILike) ToSql() (sql string, args []interface{}, err error) {
-	return Like(ilk).toSql("ILIKE")
+	return Like(ilk).toSql("ILIKE", nil)
+}
+
+func (ilk ILike) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Like(ilk).toSql("ILIKE", d)
 }
 
 // NotILike is syntactic sugar for use with ILIKE conditions.
@@ -290,7 +399,11 @@ func (ilk ILike) ToSql() (sql string, args []interface{}, err error) {
 type NotILike Like
 
 func (nilk NotILike) ToSql() (sql string, args []interface{}, err error) {
-	return Like(nilk).toSql("NOT ILIKE")
+	return Like(nilk).toSql("NOT ILIKE", nil)
+}
+
+func (nilk NotILike) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Like(nilk).toSql("NOT ILIKE", d)
 }
 
 // Lt is syntactic sugar for use with Where/Having/Set methods.
@@ -299,7 +412,7 @@ func (nilk NotILike) ToSql() (sql string, args []interface{}, err error) {
 //	.Where(Lt{"id": 1})
 type Lt map[string]interface{}
 
-func (lt Lt) toSql(opposite, orEq bool) (sql string, args []interface{}, err error) {
+func (lt Lt) toSql(opposite, orEq bool, d Dialect) (sql string, args []interface{}, err error) {
 	var (
 		exprs []string
 		opr   = "<"
@@ -318,6 +431,18 @@ func (lt Lt) toSql(opposite, orEq bool) (sql string, args []interface{}, err err
 		var expr string
 		val := lt[key]
 
+		if vs, ok := val.(Sqlizer); ok {
+			var vsql string
+			var vargs []interface{}
+			vsql, vargs, err = operandSql(vs, d)
+			if err != nil {
+				return
+			}
+			exprs = append(exprs, fmt.Sprintf("%s %s %s", key, opr, vsql))
+			args = append(args, vargs...)
+			continue
+		}
+
 		switch v := val.(type) {
 		case driver.Valuer:
 			if val, err = v.Value(); err != nil {
@@ -343,7 +468,11 @@ func (lt Lt) toSql(opposite, orEq bool) (sql string, args []interface{}, err err
 }
 
 func (lt Lt) ToSql() (sql string, args []interface{}, err error) {
-	return lt.toSql(false, false)
+	return lt.toSql(false, false, nil)
+}
+
+func (lt Lt) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return lt.toSql(false, false, d)
 }
 
 // LtOrEq is syntactic sugar for use with Where/Having/Set methods.
@@ -353,7 +482,11 @@ func (lt Lt) ToSql() (sql string, args []interface{}, err error) {
 type LtOrEq Lt
 
 func (ltOrEq LtOrEq) ToSql() (sql string, args []interface{}, err error) {
-	return Lt(ltOrEq).toSql(false, true)
+	return Lt(ltOrEq).toSql(false, true, nil)
+}
+
+func (ltOrEq LtOrEq) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Lt(ltOrEq).toSql(false, true, d)
 }
 
 // Gt is syntactic sugar for use with Where/Having/Set methods.
@@ -363,7 +496,11 @@ func (ltOrEq LtOrEq) ToSql() (sql string, args []interface{}, err error) {
 type Gt Lt
 
 func (gt Gt) ToSql() (sql string, args []interface{}, err error) {
-	return Lt(gt).toSql(true, false)
+	return Lt(gt).toSql(true, false, nil)
+}
+
+func (gt Gt) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Lt(gt).toSql(true, false, d)
 }
 
 // GtOrEq is syntactic sugar for use with Where/Having/Set methods.
@@ -373,18 +510,22 @@ func (gt Gt) ToSql() (sql string, args []interface{}, err error) {
 type GtOrEq Lt
 
 func (gtOrEq GtOrEq) ToSql() (sql string, args []interface{}, err error) {
-	return Lt(gtOrEq).toSql(true, true)
+	return Lt(gtOrEq).toSql(true, true, nil)
+}
+
+func (gtOrEq GtOrEq) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	return Lt(gtOrEq).toSql(true, true, d)
 }
 
 type conj []Sqlizer
 
-func (c conj) join(sep, defaultExpr string) (sql string, args []interface{}, err error) {
+func (c conj) join(sep, defaultExpr string, d Dialect) (sql string, args []interface{}, err error) {
 	if len(c) == 0 {
 		return defaultExpr, []interface{}{}, nil
 	}
 	var sqlParts []string
 	for _, sqlizer := range c {
-		partSQL, partArgs, err := nestedToSql(sqlizer)
+		partSQL, partArgs, err := nestedToSql(sqlizer, d)
 		if err != nil {
 			return "", nil, err
 		}
@@ -403,14 +544,95 @@ func (c conj) join(sep, defaultExpr string) (sql string, args []interface{}, err
 type And conj
 
 func (a And) ToSql() (string, []interface{}, error) {
-	return conj(a).join(" AND ", sqlTrue)
+	return conj(a).join(" AND ", sqlTrue, nil)
+}
+
+func (a And) toSqlDialect(d Dialect) (string, []interface{}, error) {
+	return conj(a).join(" AND ", sqlTrue, d)
 }
 
 // Or conjunction Sqlizers
 type Or conj
 
 func (o Or) ToSql() (string, []interface{}, error) {
-	return conj(o).join(" OR ", sqlFalse)
+	return conj(o).join(" OR ", sqlFalse, nil)
+}
+
+func (o Or) toSqlDialect(d Dialect) (string, []interface{}, error) {
+	return conj(o).join(" OR ", sqlFalse, d)
+}
+
+type existsExpr struct {
+	query Sqlizer
+	not   bool
+}
+
+// Exists builds an EXISTS (<query>) predicate.
+//
+// Ex:
+//
+//	.Where(Exists(Select("1").From("posts").Where("posts.author_id = users.id")))
+func Exists(query Sqlizer) Sqlizer {
+	return existsExpr{query: query}
+}
+
+// NotExists builds a NOT EXISTS (<query>) predicate.
+func NotExists(query Sqlizer) Sqlizer {
+	return existsExpr{query: query, not: true}
+}
+
+func (e existsExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e existsExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	sql, args, err = nestedToSql(e.query, d)
+	if err != nil {
+		return
+	}
+
+	if e.not {
+		sql = fmt.Sprintf("NOT EXISTS (%s)", sql)
+	} else {
+		sql = fmt.Sprintf("EXISTS (%s)", sql)
+	}
+	return
+}
+
+type quantifiedExpr struct {
+	column     string
+	opr        string
+	quantifier string
+	query      Sqlizer
+}
+
+// Any builds a "<column> <opr> ANY (<query>)" predicate, comparing column to
+// each row of the query.
+//
+// Ex:
+//
+//	.Where(Any("<", "price", Select("price").From("competitors")))
+func Any(opr, column string, query Sqlizer) Sqlizer {
+	return quantifiedExpr{column: column, opr: opr, quantifier: "ANY", query: query}
+}
+
+// All builds a "<column> <opr> ALL (<query>)" predicate.
+//
+// See Any for more information.
+func All(opr, column string, query Sqlizer) Sqlizer {
+	return quantifiedExpr{column: column, opr: opr, quantifier: "ALL", query: query}
+}
+
+func (e quantifiedExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e quantifiedExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	sql, args, err = nestedToSql(e.query, d)
+	if err == nil {
+		sql = fmt.Sprintf("%s %s %s (%s)", e.column, e.opr, e.quantifier, sql)
+	}
+	return
 }
 
 func getSortedKeys(exp map[string]interface{}) []string {
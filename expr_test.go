@@ -3,6 +3,7 @@ package squirrelly
 import (
 	"database/sql"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -409,6 +410,73 @@ func TestSqlLtOrder(t *testing.T) {
 	assert.Equal(t, expectedArgs, args)
 }
 
+func TestSqlLikeOrder(t *testing.T) {
+	b := Like{"c": "%3", "a": "%1", "b": "%2"}
+	sql, args, err := b.ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "a LIKE ? AND b LIKE ? AND c LIKE ?"
+	assert.Equal(t, expectedSql, sql)
+
+	expectedArgs := []interface{}{"%1", "%2", "%3"}
+	assert.Equal(t, expectedArgs, args)
+}
+
+// TestMapPredicatesDeterministic checks that every map based predicate renders
+// its keys in sorted order, however the map was built, and renders the same
+// SQL and args every time.
+func TestMapPredicatesDeterministic(t *testing.T) {
+	predicates := map[string]func(m map[string]interface{}) Sqlizer{
+		"Eq":       func(m map[string]interface{}) Sqlizer { return Eq(m) },
+		"NotEq":    func(m map[string]interface{}) Sqlizer { return NotEq(m) },
+		"Lt":       func(m map[string]interface{}) Sqlizer { return Lt(m) },
+		"LtOrEq":   func(m map[string]interface{}) Sqlizer { return LtOrEq(m) },
+		"Gt":       func(m map[string]interface{}) Sqlizer { return Gt(m) },
+		"GtOrEq":   func(m map[string]interface{}) Sqlizer { return GtOrEq(m) },
+		"Like":     func(m map[string]interface{}) Sqlizer { return Like(m) },
+		"NotLike":  func(m map[string]interface{}) Sqlizer { return NotLike(m) },
+		"ILike":    func(m map[string]interface{}) Sqlizer { return ILike(m) },
+		"NotILike": func(m map[string]interface{}) Sqlizer { return NotILike(m) },
+	}
+
+	property := func(values map[string]int) bool {
+		m := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			m[key] = value
+		}
+		keys := getSortedKeys(m)
+
+		for name, predicate := range predicates {
+			sql, args, err := predicate(m).ToSql()
+			if !assert.NoError(t, err, name) {
+				return false
+			}
+
+			for i := 0; i < 10; i++ {
+				// rebuild the map, so its iteration order changes
+				rebuilt := make(map[string]interface{}, len(m))
+				for key, value := range m {
+					rebuilt[key] = value
+				}
+
+				againSql, againArgs, err := predicate(rebuilt).ToSql()
+				if !assert.NoError(t, err, name) || !assert.Equal(t, sql, againSql, name) || !assert.Equal(t, args, againArgs, name) {
+					return false
+				}
+			}
+
+			for idx, key := range keys {
+				if !assert.Equal(t, m[key], args[idx], name) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	assert.NoError(t, quick.Check(property, &quick.Config{MaxCount: 100}))
+}
+
 func TestExprEscaped(t *testing.T) {
 	b := Expr("count(??)", Expr("x"))
 	sql, args, err := b.ToSql()
@@ -462,3 +530,147 @@ func ExampleEq() {
 		"company": 20,
 	})
 }
+
+func TestEqSubqueryToSql(t *testing.T) {
+	active := Select("id").From("users").Where("active = ?", true).PlaceholderFormat(Dollar)
+
+	sql, args, err := Select("*").
+		From("posts").
+		Where("draft = ?", false).
+		Where(Eq{"author_id": active, "editor_id": Expr("(SELECT max(id) FROM users WHERE admin = ?)", true)}).
+		Where(NotEq{"reviewer_id": Union(Select("id").From("bots"), Select("id").From("banned").Where("until > ?", 5))}).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT * FROM posts WHERE draft = $1 " +
+		"AND author_id IN (SELECT id FROM users WHERE active = $2) " +
+		"AND editor_id = (SELECT max(id) FROM users WHERE admin = $3) " +
+		"AND reviewer_id NOT IN (SELECT id FROM bots UNION SELECT id FROM banned WHERE until > $4)"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{false, true, true, 5}, args)
+
+	_, _, err = Eq{"id": Select()}.ToSql()
+	assert.Error(t, err)
+}
+
+func TestExistsToSql(t *testing.T) {
+	posts := Select("1").From("posts").Where("posts.author_id = users.id AND posts.score > ?", 10)
+
+	sql, args, err := Select("id").
+		From("users").
+		Where("active = ?", true).
+		Where(Exists(posts)).
+		Where(NotExists(Select("1").From("bans").Where("bans.user_id = users.id").PlaceholderFormat(Dollar))).
+		Where(Any(">", "age", Select("age").From("minors").Where("country = ?", "nz"))).
+		Where(All("<>", "id", Select("user_id").From("admins"))).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT id FROM users WHERE active = $1 " +
+		"AND EXISTS (SELECT 1 FROM posts WHERE posts.author_id = users.id AND posts.score > $2) " +
+		"AND NOT EXISTS (SELECT 1 FROM bans WHERE bans.user_id = users.id) " +
+		"AND age > ANY (SELECT age FROM minors WHERE country = $3) " +
+		"AND id <> ALL (SELECT user_id FROM admins)"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{true, 10, "nz"}, args)
+
+	_, _, err = Exists(Select()).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Any("=", "id", Select()).ToSql()
+	assert.Error(t, err)
+}
+
+func TestExprNestedPlaceholderFormat(t *testing.T) {
+	sub := Select("id").From("t").Where("a = ?", 1).PlaceholderFormat(Dollar)
+
+	sql, args, err := Select("*").
+		From("u").
+		Where("b = ?", 2).
+		Where(Expr("id IN (?)", sub)).
+		Where(ConcatExpr("c = ", Expr("?", 3))).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM u WHERE b = $1 AND id IN (SELECT id FROM t WHERE a = $2) AND c = $3", sql)
+	assert.Equal(t, []interface{}{2, 1, 3}, args)
+}
+
+func TestColToSql(t *testing.T) {
+	type testCase struct {
+		pred         Sqlizer
+		expectedSql  string
+		expectedArgs []interface{}
+	}
+
+	cases := []testCase{
+		{Eq{"a.user_id": Col("b.id"), "a.kind": "x"}, "a.kind = ? AND a.user_id = b.id", []interface{}{"x"}},
+		{NotEq{"a.user_id": Col("b.id")}, "a.user_id <> b.id", nil},
+		{Lt{"a.at": Col("b.at")}, "a.at < b.at", nil},
+		{GtOrEq{"a.at": Col("b.at"), "a.n": 1}, "a.at >= b.at AND a.n >= ?", []interface{}{1}},
+		{Like{"a.name": Col("b.pattern")}, "a.name LIKE b.pattern", nil},
+		{Eq{"a.at": Func("now")}, "a.at = now()", nil},
+		{NotEq{"a.n": Add(Col("b.n"), 1)}, "a.n <> (b.n + ?)", []interface{}{1}},
+		{Lt{"a.at": Func("now")}, "a.at < now()", nil},
+		{NotILike{"a.name": Col("b.pattern")}, "a.name NOT ILIKE b.pattern", nil},
+	}
+
+	for _, c := range cases {
+		sql, args, err := c.pred.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+		assert.Equal(t, c.expectedArgs, args)
+	}
+}
+
+func TestColQuoted(t *testing.T) {
+	type testCase struct {
+		dialect     Dialect
+		col         Col
+		expectedSql string
+	}
+
+	cases := []testCase{
+		{nil, "b.id", "b.id"},
+		{Postgres, "b.id", `"b"."id"`},
+		{MySql, "b.*", "`b`.*"},
+		{SqlServer, "order", "[order]"},
+	}
+
+	for _, c := range cases {
+		sql, _, err := nestedToSql(c.col, c.dialect)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+	}
+
+	sql, args, err := Update("accounts a").
+		Set("owner_id", Col("u.id")).
+		From("users u").
+		Where(Eq{"u.email": Col("a.email"), "u.active": true}).
+		Where(Or{Lt{"u.created_at": Col("a.created_at")}, Like{"u.name": Func("concat", Col("a.name"), "%")}}).
+		Dialect(Postgres).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, `UPDATE accounts a SET owner_id = "u"."id" FROM users u `+
+		`WHERE u.active = $1 AND u.email = "a"."email" `+
+		`AND (u.created_at < "a"."created_at" OR u.name LIKE concat("a"."name", $2))`, sql)
+	assert.Equal(t, []interface{}{true, "%"}, args)
+
+	sql, _, err = Select("a").From("t").Where(Eq{"a": Col("b.id")}).Dialect(Postgres).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT a FROM t WHERE a = "b"."id"`, sql)
+}
+
+func TestLtSqlizerToSql(t *testing.T) {
+	sql, args, err := Lt{"price": Select("avg(price)").From("products").Where("kind = ?", "book")}.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "price < (SELECT avg(price) FROM products WHERE kind = ?)", sql)
+	assert.Equal(t, []interface{}{"book"}, args)
+
+	sql, args, err = Like{"name": Func("concat", Col("prefix"), "%")}.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "name LIKE concat(prefix, ?)", sql)
+	assert.Equal(t, []interface{}{"%"}, args)
+}
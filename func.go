@@ -0,0 +1,356 @@
+package squirrelly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The operands of the expressions in this file are either a Sqlizer, such as a
+// Col or another expression, which is rendered in place, or a value, which is
+// bound to a placeholder. Subqueries are parenthesised.
+
+// operandSql renders an operand of an expression for dialect d.
+func operandSql(operand interface{}, d Dialect) (string, []interface{}, error) {
+	s, ok := operand.(Sqlizer)
+	if !ok {
+		return "?", []interface{}{operand}, nil
+	}
+
+	sql, args, err := nestedToSql(s, d)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, ok := s.(Subquery); ok {
+		sql = fmt.Sprintf("(%s)", sql)
+	}
+	return sql, args, nil
+}
+
+// operandsSql renders operands for dialect d, separated by sep.
+func operandsSql(operands []interface{}, sep string, d Dialect) (string, []interface{}, error) {
+	sqls := make([]string, len(operands))
+	var args []interface{}
+	for idx, operand := range operands {
+		opSql, opArgs, err := operandSql(operand, d)
+		if err != nil {
+			return "", nil, err
+		}
+		sqls[idx] = opSql
+		args = append(args, opArgs...)
+	}
+	return strings.Join(sqls, sep), args, nil
+}
+
+type notExpr struct {
+	pred Sqlizer
+}
+
+// Not negates the predicate pred.
+//
+// Ex:
+//
+//	.Where(Not(Eq{"status": "active"})) == "NOT (status = ?)"
+func Not(pred Sqlizer) Sqlizer {
+	return notExpr{pred}
+}
+
+func (e notExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e notExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	if e.pred == nil {
+		err = errors.New("cannot negate a nil predicate")
+		return
+	}
+
+	sql, args, err = nestedToSql(e.pred, d)
+	if err == nil {
+		sql = fmt.Sprintf("NOT (%s)", sql)
+	}
+	return
+}
+
+type betweenExpr struct {
+	column string
+	low    interface{}
+	high   interface{}
+}
+
+// Between builds a "<column> BETWEEN <low> AND <high>" predicate.
+//
+// Ex:
+//
+//	.Where(Between("created_at", start, end))
+func Between(column string, low, high interface{}) Sqlizer {
+	return betweenExpr{column, low, high}
+}
+
+func (e betweenExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e betweenExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	bounds, args, err := operandsSql([]interface{}{e.low, e.high}, " AND ", d)
+	if err == nil {
+		sql = fmt.Sprintf("%s BETWEEN %s", e.column, bounds)
+	}
+	return
+}
+
+type nullExpr struct {
+	column string
+	not    bool
+}
+
+// IsNull builds a "<column> IS NULL" predicate.
+func IsNull(column string) Sqlizer {
+	return nullExpr{column: column}
+}
+
+// IsNotNull builds a "<column> IS NOT NULL" predicate.
+func IsNotNull(column string) Sqlizer {
+	return nullExpr{column: column, not: true}
+}
+
+func (e nullExpr) ToSql() (sql string, args []interface{}, err error) {
+	if e.not {
+		return fmt.Sprintf("%s IS NOT NULL", e.column), nil, nil
+	}
+	return fmt.Sprintf("%s IS NULL", e.column), nil, nil
+}
+
+type funcExpr struct {
+	name string
+	args []interface{}
+}
+
+// Func builds a call of the SQL function name with args.
+//
+// Ex:
+//
+//	Func("lower", Col("email")) == "lower(email)"
+//	Func("date_trunc", "day", Col("created_at")) == "date_trunc(?, created_at)"
+func Func(name string, args ...interface{}) Sqlizer {
+	return funcExpr{name, args}
+}
+
+// Coalesce builds a COALESCE(<values>) expression, which is the first of
+// values that isn't NULL.
+//
+// Ex:
+//
+//	Coalesce(Col("nickname"), Col("name"), "anonymous")
+func Coalesce(values ...interface{}) Sqlizer {
+	return funcExpr{"COALESCE", values}
+}
+
+func (e funcExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e funcExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	sql, args, err = operandsSql(e.args, ", ", d)
+	if err == nil {
+		sql = fmt.Sprintf("%s(%s)", e.name, sql)
+	}
+	return
+}
+
+type castExpr struct {
+	value interface{}
+	typ   string
+}
+
+// Cast builds a CAST(<value> AS <typ>) expression.
+func Cast(value interface{}, typ string) Sqlizer {
+	return castExpr{value, typ}
+}
+
+func (e castExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e castExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	sql, args, err = operandSql(e.value, d)
+	if err == nil {
+		sql = fmt.Sprintf("CAST(%s AS %s)", sql, e.typ)
+	}
+	return
+}
+
+type arithmeticExpr struct {
+	opr      string
+	operands []interface{}
+}
+
+// Add builds a parenthesised sum of operands.
+//
+// Ex:
+//
+//	Set("balance", Add(Col("balance"), Mul(Col("rate"), 2))) == "balance = (balance + (rate * ?))"
+func Add(operands ...interface{}) Sqlizer {
+	return arithmeticExpr{"+", operands}
+}
+
+// Sub builds a parenthesised subtraction of operands, from left to right.
+func Sub(operands ...interface{}) Sqlizer {
+	return arithmeticExpr{"-", operands}
+}
+
+// Mul builds a parenthesised product of operands.
+func Mul(operands ...interface{}) Sqlizer {
+	return arithmeticExpr{"*", operands}
+}
+
+// Div builds a parenthesised division of operands, from left to right.
+func Div(operands ...interface{}) Sqlizer {
+	return arithmeticExpr{"/", operands}
+}
+
+func (e arithmeticExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e arithmeticExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	if len(e.operands) < 2 {
+		err = fmt.Errorf("the %s operator needs at least two operands", e.opr)
+		return
+	}
+
+	sql, args, err = operandsSql(e.operands, " "+e.opr+" ", d)
+	if err == nil {
+		sql = fmt.Sprintf("(%s)", sql)
+	}
+	return
+}
+
+type aggregateData struct {
+	Function   string
+	Distinct   bool
+	Operand    interface{}
+	FilterWhen []Sqlizer
+}
+
+// ToSql renders the aggregate function call, followed by its FILTER clause.
+func (d *aggregateData) ToSql() (string, []interface{}, error) {
+	return d.toSqlDialect(nil)
+}
+
+func (d *aggregateData) toSqlDialect(dialect Dialect) (sqlStr string, args []interface{}, err error) {
+	opSql, opArgs, err := operandSql(d.Operand, dialect)
+	if err != nil {
+		return
+	}
+
+	filter := &bytes.Buffer{}
+	filterArgs, err := appendToSql(d.FilterWhen, dialect, filter, " AND ", nil)
+	if err != nil {
+		return
+	}
+	filterClause := filter.Len() > 0 && dialectOrDefault(dialect).AggregateFilter()
+
+	sql := &bytes.Buffer{}
+	sql.WriteString(d.Function)
+	sql.WriteString("(")
+	if d.Distinct {
+		sql.WriteString("DISTINCT ")
+	}
+
+	if filter.Len() > 0 && !filterClause {
+		// rows that don't match the filter are aggregated as NULL, which
+		// aggregates skip, and COUNT(*) counts the rows that do as 1
+		if opSql == "*" {
+			opSql = "1"
+		}
+		fmt.Fprintf(sql, "CASE WHEN %s THEN %s END", filter, opSql)
+		args = append(filterArgs, opArgs...)
+	} else {
+		sql.WriteString(opSql)
+		args = opArgs
+	}
+	sql.WriteString(")")
+
+	if filterClause {
+		fmt.Fprintf(sql, " FILTER (WHERE %s)", filter)
+		args = append(args, filterArgs...)
+	}
+
+	sqlStr = sql.String()
+	return
+}
+
+// Builder
+
+// AggregateBuilder builds aggregate function calls, such as COUNT or SUM,
+// which may be restricted to some rows with a FILTER clause:
+//
+//	Select("dept").
+//		Column(Alias(Count(Col("*")), "total")).
+//		Column(Alias(Sum(Col("salary")).Filter(Eq{"active": true}), "active_salaries")).
+//		From("employees").
+//		GroupBy("dept")
+//
+// An AggregateBuilder can also be used as the function of a Window.
+type AggregateBuilder struct {
+	data aggregateData
+}
+
+func newAggregateBuilder(function string, operand interface{}) AggregateBuilder {
+	return AggregateBuilder{data: aggregateData{Function: function, Operand: operand}}
+}
+
+// Count builds a COUNT(<operand>) aggregate. Use Col("*") to count rows.
+func Count(operand interface{}) AggregateBuilder {
+	return newAggregateBuilder("COUNT", operand)
+}
+
+// Sum builds a SUM(<operand>) aggregate.
+func Sum(operand interface{}) AggregateBuilder {
+	return newAggregateBuilder("SUM", operand)
+}
+
+// Min builds a MIN(<operand>) aggregate.
+func Min(operand interface{}) AggregateBuilder {
+	return newAggregateBuilder("MIN", operand)
+}
+
+// Max builds a MAX(<operand>) aggregate.
+func Max(operand interface{}) AggregateBuilder {
+	return newAggregateBuilder("MAX", operand)
+}
+
+// Avg builds an AVG(<operand>) aggregate.
+func Avg(operand interface{}) AggregateBuilder {
+	return newAggregateBuilder("AVG", operand)
+}
+
+// ToSql builds the aggregate into a SQL string and bound args.
+func (b AggregateBuilder) ToSql() (string, []interface{}, error) {
+	return b.data.ToSql()
+}
+
+func (b AggregateBuilder) toSqlDialect(d Dialect) (string, []interface{}, error) {
+	return b.data.toSqlDialect(d)
+}
+
+// Distinct only aggregates distinct values of the operand.
+func (b AggregateBuilder) Distinct() AggregateBuilder {
+	b.data.Distinct = true
+	return b
+}
+
+// Filter adds a FILTER (WHERE ...) clause to the aggregate, which only
+// aggregates the rows matching pred.
+//
+// Predicates are ANDed together, and accept the same types as
+// SelectBuilder.Where. If the Dialect of the statement has no FILTER clause,
+// as with MySql and SqlServer, the aggregate's operand is filtered instead:
+//
+//	Sum(Col("salary")).Filter(Eq{"active": true}) == "SUM(CASE WHEN active = ? THEN salary END)"
+func (b AggregateBuilder) Filter(pred interface{}, args ...interface{}) AggregateBuilder {
+	b.data.FilterWhen = appendCopy(b.data.FilterWhen, newWherePart(pred, args...))
+	return b
+}
@@ -0,0 +1,115 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncExprsToSql(t *testing.T) {
+	type testCase struct {
+		expr         Sqlizer
+		expectedSql  string
+		expectedArgs []interface{}
+	}
+
+	cases := []testCase{
+		{Not(Eq{"status": "active"}), "NOT (status = ?)", []interface{}{"active"}},
+		{Not(Or{Eq{"a": 1}, IsNull("b")}), "NOT ((a = ? OR b IS NULL))", []interface{}{1}},
+		{Between("age", 18, 65), "age BETWEEN ? AND ?", []interface{}{18, 65}},
+		{Between("at", Col("starts_at"), Func("now")), "at BETWEEN starts_at AND now()", nil},
+		{IsNull("deleted_at"), "deleted_at IS NULL", nil},
+		{IsNotNull("deleted_at"), "deleted_at IS NOT NULL", nil},
+		{Coalesce(Col("nickname"), Col("name"), "anonymous"), "COALESCE(nickname, name, ?)", []interface{}{"anonymous"}},
+		{Cast(Col("price"), "numeric(10,2)"), "CAST(price AS numeric(10,2))", nil},
+		{Cast("42", "integer"), "CAST(? AS integer)", []interface{}{"42"}},
+		{Func("date_trunc", "day", Col("created_at")), "date_trunc(?, created_at)", []interface{}{"day"}},
+		{Add(Col("a"), 1, Col("b")), "(a + ? + b)", []interface{}{1}},
+		{Sub(Col("a"), Mul(Col("b"), 2)), "(a - (b * ?))", []interface{}{2}},
+		{Div(Sum(Col("x")), Count(Col("*"))), "(SUM(x) / COUNT(*))", nil},
+		{Count(Col("user_id")).Distinct(), "COUNT(DISTINCT user_id)", nil},
+		{Min(Col("price")), "MIN(price)", nil},
+		{Max(Col("price")).Filter("kind = ?", "new"), "MAX(price) FILTER (WHERE kind = ?)", []interface{}{"new"}},
+		{Avg(Col("score")).Filter(Eq{"a": 1}).Filter(Gt{"b": 2}), "AVG(score) FILTER (WHERE a = ? AND b > ?)", []interface{}{1, 2}},
+		{Func("greatest", Select("max(x)").From("t").Where("y = ?", 3), 4), "greatest((SELECT max(x) FROM t WHERE y = ?), ?)", []interface{}{3, 4}},
+	}
+
+	for _, c := range cases {
+		sql, args, err := c.expr.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+		assert.Equal(t, c.expectedArgs, args)
+	}
+}
+
+func TestFuncExprsToSqlErr(t *testing.T) {
+	_, _, err := Not(nil).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Add(1).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Coalesce(Col("a"), Select()).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Sum(Col("a")).Filter(1).ToSql()
+	assert.Error(t, err)
+}
+
+func TestFuncExprsInBuilders(t *testing.T) {
+	sql, args, err := Select("dept").
+		Column(Alias(Count(Col("*")).Filter("active = ?", true), "active")).
+		Column(Alias(Coalesce(Sum(Col("bonus")), 0), "bonuses")).
+		From("employees").
+		Where(Between("hired_at", "2020-01-01", "2021-01-01")).
+		Where(Not(Eq{"dept": "sales"})).
+		GroupBy("dept").
+		Having(Gt{"avg_salary": Avg(Col("salary")).Filter("salary > ?", 0)}).
+		OrderByClause(Max(Col("salary"))).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT dept, " +
+		"(COUNT(*) FILTER (WHERE active = $1)) AS active, " +
+		"(COALESCE(SUM(bonus), $2)) AS bonuses " +
+		"FROM employees WHERE hired_at BETWEEN $3 AND $4 AND NOT (dept = $5) " +
+		"GROUP BY dept HAVING avg_salary > AVG(salary) FILTER (WHERE salary > $6) ORDER BY MAX(salary)"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{true, 0, "2020-01-01", "2021-01-01", "sales", 0}, args)
+
+	sql, args, err = Update("accounts").
+		Set("balance", Add(Col("balance"), Mul(Col("rate"), 2))).
+		Set("name", Func("upper", Col("name"))).
+		Where(IsNotNull("rate")).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE accounts SET balance = (balance + (rate * $1)), name = upper(name) WHERE rate IS NOT NULL", sql)
+	assert.Equal(t, []interface{}{2}, args)
+}
+
+func TestAggregateFilterFallback(t *testing.T) {
+	sql, args, err := Select("dept").
+		Column(Alias(Count(Col("*")).Filter("active = ?", true), "active")).
+		Column(Alias(Sum(Col("salary")).Distinct().Filter(Eq{"active": true}).Filter("salary > ?", 0), "salaries")).
+		Column(Alias(Max(Col("salary")), "top")).
+		From("employees").
+		GroupBy("dept").
+		Dialect(SqlServer).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT dept, " +
+		"(COUNT(CASE WHEN active = @p1 THEN 1 END)) AS active, " +
+		"(SUM(DISTINCT CASE WHEN active = @p2 AND salary > @p3 THEN [salary] END)) AS salaries, " +
+		"(MAX([salary])) AS top " +
+		"FROM employees GROUP BY dept"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{true, true, 0}, args)
+
+	sql, args, err = Select().Column(Avg(Add(Col("a"), 1)).Filter("b = ?", 2)).From("t").Dialect(MySql).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT AVG(CASE WHEN b = ? THEN (`a` + ?) END) FROM t", sql)
+	assert.Equal(t, []interface{}{2, 1}, args)
+}
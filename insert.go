@@ -6,27 +6,34 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
-
-	"github.com/jmoiron/sqlx/reflectx"
-	"github.com/lann/builder"
 )
 
 type insertData struct {
-	PlaceholderFormat PlaceholderFormat
-	Prefixes          []Sqlizer
-	StatementKeyword  string
-	Options           []string
-	Into              string
-	Columns           []string
-	Values            [][]interface{}
-	Suffixes          []Sqlizer
-	Select            *SelectBuilder
-	ConflictKeys      []string
-	UpdateColumns     []string
-	DoNothing         bool
-	Returning         []string
+	PlaceholderFormat  PlaceholderFormat
+	Dialect            Dialect
+	Mapper             *Mapper
+	Prefixes           []Sqlizer
+	With               []commonTableExpr
+	StatementKeyword   string
+	Options            []string
+	Into               string
+	Columns            []string
+	Values             [][]interface{}
+	Suffixes           []Sqlizer
+	Select             Subquery
+	ConflictKeys       []string
+	ConflictConstraint string
+	ConflictWhereParts []Sqlizer
+	UpdateColumns      []string
+	UpdateSets         []UpsertSet
+	UpdateWhereParts   []Sqlizer
+	UpdateAll          bool
+	UpdateExcept       []string
+	DoNothing          bool
+	Returning          []string
 }
 
 func (d *insertData) ToSql() (sqlStr string, args []interface{}, err error) {
@@ -39,10 +46,36 @@ func (d *insertData) ToSql() (sqlStr string, args []interface{}, err error) {
 		return
 	}
 
+	dialect := dialectOrDefault(d.Dialect)
+
+	var returning string
+	var output bool
+	if len(d.Returning) > 0 {
+		returning, output, err = dialect.Returning(d.Returning, "INSERTED")
+		if err != nil {
+			return
+		}
+	}
+
+	options := d.Options
+
+	var upsert *Upsert
+	upsert, err = d.upsert()
+	if err != nil {
+		return
+	}
+	if upsert != nil {
+		for _, option := range dialect.UpsertOptions(*upsert) {
+			if !slices.Contains(options, option) {
+				options = appendCopy(options, option)
+			}
+		}
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
-		args, err = appendToSql(d.Prefixes, sql, " ", args)
+		args, err = appendToSql(d.Prefixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
@@ -50,6 +83,11 @@ func (d *insertData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(" ")
 	}
 
+	args, err = appendWithToSql(d.With, d.Dialect, sql, args)
+	if err != nil {
+		return
+	}
+
 	if d.StatementKeyword == "" {
 		sql.WriteString("INSERT ")
 	} else {
@@ -57,8 +95,8 @@ func (d *insertData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(" ")
 	}
 
-	if len(d.Options) > 0 {
-		sql.WriteString(strings.Join(d.Options, " "))
+	if len(options) > 0 {
+		sql.WriteString(strings.Join(options, " "))
 		sql.WriteString(" ")
 	}
 
@@ -72,6 +110,11 @@ func (d *insertData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(") ")
 	}
 
+	if output {
+		sql.WriteString(returning)
+		sql.WriteString(" ")
+	}
+
 	if d.Select != nil {
 		args, err = d.appendSelectToSQL(sql, args)
 	} else {
@@ -81,52 +124,74 @@ func (d *insertData) ToSql() (sqlStr string, args []interface{}, err error) {
 		return
 	}
 
-	if len(d.ConflictKeys) > 0 {
-		sql.WriteString(" ON CONFLICT (")
-		sql.WriteString(strings.Join(d.ConflictKeys, ","))
-		sql.WriteString(")")
-
-		if d.DoNothing {
-			if len(d.UpdateColumns) > 0 {
-				err = errors.New("insert statements with OnConflict can't use both UpdateColumns and DoNothing")
-				return
-			}
-
-			sql.WriteString(" DO NOTHING")
-		} else {
-			if len(d.UpdateColumns) > 0 {
-				sql.WriteString(" DO UPDATE SET")
-				for idx, col := range d.UpdateColumns {
-					if idx != 0 {
-						sql.WriteString(",")
-					}
+	if upsert != nil {
+		var upsertSql string
+		var upsertArgs []interface{}
+		upsertSql, upsertArgs, err = dialect.Upsert(*upsert)
+		if err != nil {
+			return
+		}
 
-					sql.WriteString(fmt.Sprintf(" %[1]s = EXCLUDED.%[1]s", col))
-				}
-			} else {
-				err = errors.New("insert statements with OnConflict set must have at least one column to be updated")
-				return
-			}
+		if upsertSql != "" {
+			sql.WriteString(" ")
+			sql.WriteString(upsertSql)
+			args = append(args, upsertArgs...)
 		}
 	}
 
-	if len(d.Returning) > 0 {
-		sql.WriteString(" RETURNING ")
-		sql.WriteString(strings.Join(d.Returning, ","))
+	if returning != "" && !output {
+		sql.WriteString(" ")
+		sql.WriteString(returning)
 	}
 
 	if len(d.Suffixes) > 0 {
 		sql.WriteString(" ")
-		args, err = appendToSql(d.Suffixes, sql, " ", args)
+		args, err = appendToSql(d.Suffixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, d.Dialect, sql.String())
 	return
 }
 
+// upsert returns the Upsert described by the builder's OnConflict and related methods, or nil if the insert isn't an upsert.
+func (d *insertData) upsert() (*Upsert, error) {
+	if len(d.ConflictKeys) == 0 && d.ConflictConstraint == "" && len(d.ConflictWhereParts) == 0 &&
+		len(d.UpdateColumns) == 0 && len(d.UpdateSets) == 0 && len(d.UpdateWhereParts) == 0 && !d.UpdateAll && !d.DoNothing {
+		return nil, nil
+	}
+
+	updateColumns := d.UpdateColumns
+	if d.UpdateAll {
+		if len(d.Columns) == 0 {
+			return nil, errors.New("insert statements with UpdateAllExcept must specify their columns")
+		}
+
+		for _, col := range d.Columns {
+			if slices.Contains(d.ConflictKeys, col) || slices.Contains(d.UpdateExcept, col) || slices.Contains(updateColumns, col) {
+				continue
+			}
+			if slices.ContainsFunc(d.UpdateSets, func(set UpsertSet) bool { return set.Column == col }) {
+				continue
+			}
+			updateColumns = appendCopy(updateColumns, col)
+		}
+	}
+
+	return &Upsert{
+		ConflictKeys:       d.ConflictKeys,
+		ConflictConstraint: d.ConflictConstraint,
+		ConflictWhere:      d.ConflictWhereParts,
+		UpdateColumns:      updateColumns,
+		UpdateSets:         d.UpdateSets,
+		UpdateWhere:        d.UpdateWhereParts,
+		DoNothing:          d.DoNothing,
+		Select:             d.Select != nil,
+	}, nil
+}
+
 func (d *insertData) appendValuesToSQL(w io.Writer, args []interface{}) ([]interface{}, error) {
 	if len(d.Values) == 0 {
 		return args, errors.New("values for insert statements are not set")
@@ -139,7 +204,7 @@ func (d *insertData) appendValuesToSQL(w io.Writer, args []interface{}) ([]inter
 		valueStrings := make([]string, len(row))
 		for v, val := range row {
 			if vs, ok := val.(Sqlizer); ok {
-				vsql, vargs, err := vs.ToSql()
+				vsql, vargs, err := nestedToSql(vs, d.Dialect)
 				if err != nil {
 					return nil, err
 				}
@@ -163,7 +228,7 @@ func (d *insertData) appendSelectToSQL(w io.Writer, args []interface{}) ([]inter
 		return args, errors.New("select clause for insert statements are not set")
 	}
 
-	selectClause, sArgs, err := d.Select.ToSql()
+	selectClause, sArgs, err := nestedToSql(d.Select, d.Dialect)
 	if err != nil {
 		return args, err
 	}
@@ -177,10 +242,8 @@ func (d *insertData) appendSelectToSQL(w io.Writer, args []interface{}) ([]inter
 // Builder
 
 // InsertBuilder builds SQL INSERT statements.
-type InsertBuilder builder.Builder
-
-func init() {
-	builder.Register(InsertBuilder{}, insertData{})
+type InsertBuilder struct {
+	data insertData
 }
 
 // Format methods
@@ -188,15 +251,34 @@ func init() {
 // PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
 // query.
 func (b InsertBuilder) PlaceholderFormat(f PlaceholderFormat) InsertBuilder {
-	return builder.Set(b, "PlaceholderFormat", f).(InsertBuilder)
+	b.data.PlaceholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect used to render the query, along with its
+// PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b InsertBuilder) Dialect(d Dialect) InsertBuilder {
+	b.data.Dialect = d
+	b.data.PlaceholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// Mapper sets the Mapper used to map struct fields to columns, instead of the
+// package level default.
+func (b InsertBuilder) Mapper(m *Mapper) InsertBuilder {
+	b.data.Mapper = m
+	return b
 }
 
 // SQL methods
 
 // ToSql builds the query into a SQL string and bound args.
 func (b InsertBuilder) ToSql() (string, []interface{}, error) {
-	data := builder.GetStruct(b).(insertData)
-	return data.ToSql()
+	return b.data.ToSql()
+}
+
+func (b InsertBuilder) debugFormat() (PlaceholderFormat, Dialect) {
+	return b.data.PlaceholderFormat, b.data.Dialect
 }
 
 // MustSql builds the query into a SQL string and bound args.
@@ -216,27 +298,58 @@ func (b InsertBuilder) Prefix(sql string, args ...interface{}) InsertBuilder {
 
 // PrefixExpr adds an expression to the very beginning of the query
 func (b InsertBuilder) PrefixExpr(expr Sqlizer) InsertBuilder {
-	return builder.Append(b, "Prefixes", expr).(InsertBuilder)
+	b.data.Prefixes = appendCopy(b.data.Prefixes, expr)
+	return b
+}
+
+// With adds a common table expression to the WITH clause of the query.
+//
+// See SelectBuilder.With for more information.
+func (b InsertBuilder) With(name string, query Subquery) InsertBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression to the WITH clause
+// of the query.
+//
+// See SelectBuilder.WithRecursive for more information.
+func (b InsertBuilder) WithRecursive(name string, columns []string, query Subquery) InsertBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, columns: columns, query: query, recursive: true})
+	return b
+}
+
+// WithMaterialized adds a materialized common table expression to the WITH
+// clause of the query.
+//
+// See SelectBuilder.WithMaterialized for more information.
+func (b InsertBuilder) WithMaterialized(name string, query Subquery) InsertBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query, materialized: true})
+	return b
 }
 
 // Options adds keyword options before the INTO clause of the query.
 func (b InsertBuilder) Options(options ...string) InsertBuilder {
-	return builder.Extend(b, "Options", options).(InsertBuilder)
+	b.data.Options = appendCopy(b.data.Options, options...)
+	return b
 }
 
 // Into sets the INTO clause of the query.
 func (b InsertBuilder) Into(from string) InsertBuilder {
-	return builder.Set(b, "Into", from).(InsertBuilder)
+	b.data.Into = from
+	return b
 }
 
 // Columns adds insert columns to the query.
 func (b InsertBuilder) Columns(columns ...string) InsertBuilder {
-	return builder.Extend(b, "Columns", columns).(InsertBuilder)
+	b.data.Columns = appendCopy(b.data.Columns, columns...)
+	return b
 }
 
 // Values adds a single row's values to the query.
 func (b InsertBuilder) Values(values ...interface{}) InsertBuilder {
-	return builder.Append(b, "Values", values).(InsertBuilder)
+	b.data.Values = appendCopy(b.data.Values, values)
+	return b
 }
 
 // Suffix adds an expression to the end of the query
@@ -246,7 +359,8 @@ func (b InsertBuilder) Suffix(sql string, args ...interface{}) InsertBuilder {
 
 // SuffixExpr adds an expression to the end of the query
 func (b InsertBuilder) SuffixExpr(expr Sqlizer) InsertBuilder {
-	return builder.Append(b, "Suffixes", expr).(InsertBuilder)
+	b.data.Suffixes = appendCopy(b.data.Suffixes, expr)
+	return b
 }
 
 // SetMap set columns and values for insert builder from a map of column name and value
@@ -264,40 +378,102 @@ func (b InsertBuilder) SetMap(clauses map[string]interface{}) InsertBuilder {
 		vals = append(vals, clauses[col])
 	}
 
-	b = builder.Set(b, "Columns", cols).(InsertBuilder)
-	b = builder.Set(b, "Values", [][]interface{}{vals}).(InsertBuilder)
+	b.data.Columns = cols
+	b.data.Values = [][]interface{}{vals}
 
 	return b
 }
 
 // OnConflict is used to turn an insert into an upsert. This is used to add the ON CONFLICT (keys ...) clause. When used with [InsertBuilder.UpdateColumns] the insert builder adds ON CONFLICT (keys ...) DO UPDATE SET ....
+//
+// The upsert is rendered by the builder's Dialect. MySQL renders ON DUPLICATE KEY UPDATE clauses instead, checking every unique key of the
+// table, so its conflict keys can be left out, and [InsertBuilder.DoNothing] renders INSERT IGNORE.
 func (b InsertBuilder) OnConflict(conflictKeys ...string) InsertBuilder {
-	return builder.Extend(b, "ConflictKeys", conflictKeys).(InsertBuilder)
+	b.data.ConflictKeys = appendCopy(b.data.ConflictKeys, conflictKeys...)
+	return b
 }
 
 // DoNothing, when used with [InsertBuilder.OnConflict], generates ON CONFLICT DO NOTHING clause to the insert builder.
 func (b InsertBuilder) DoNothing() InsertBuilder {
-	return builder.Set(b, "DoNothing", true).(InsertBuilder)
+	b.data.DoNothing = true
+	return b
 }
 
 // UpdateColumns, when used with [InsertBuilder.OnConflict], generates ON CONFLICT DO UPDATE clauses to the insert builder.
 func (b InsertBuilder) UpdateColumns(columns ...string) InsertBuilder {
-	return builder.Extend(b, "UpdateColumns", columns).(InsertBuilder)
+	b.data.UpdateColumns = appendCopy(b.data.UpdateColumns, columns...)
+	return b
+}
+
+// OnConflictConstraint turns an insert into an upsert triggered by a conflict on the named constraint, adding an
+// ON CONFLICT ON CONSTRAINT name clause. It can't be used along with conflict keys set by [InsertBuilder.OnConflict].
+func (b InsertBuilder) OnConflictConstraint(name string) InsertBuilder {
+	b.data.ConflictConstraint = name
+	return b
+}
+
+// OnConflictWhere adds the predicate of a partial unique index to the conflict target set by [InsertBuilder.OnConflict],
+// generating ON CONFLICT (keys ...) WHERE ....
+//
+// See SelectBuilder.Where for the accepted predicates.
+func (b InsertBuilder) OnConflictWhere(pred interface{}, args ...interface{}) InsertBuilder {
+	b.data.ConflictWhereParts = appendCopy(b.data.ConflictWhereParts, newWherePart(pred, args...))
+	return b
+}
+
+// UpdateSet, when used with [InsertBuilder.OnConflict], updates column to value on conflict. value may be a Sqlizer,
+// which allows expressions such as:
+//
+//	Insert("counters").
+//		Columns("name", "counter").
+//		Values("visits", 1).
+//		OnConflict("name").
+//		UpdateSet("counter", Expr("counters.counter + EXCLUDED.counter"))
+//
+// UpdateSet clauses are rendered after those of [InsertBuilder.UpdateColumns].
+func (b InsertBuilder) UpdateSet(column string, value interface{}) InsertBuilder {
+	b.data.UpdateSets = appendCopy(b.data.UpdateSets, UpsertSet{Column: column, Value: value})
+	return b
 }
 
-// Returning adds a RETURNING <columns> suffix (before the [InsertBuilder.Suffix]) to the insert builder.
+// UpdateWhere, when used with [InsertBuilder.OnConflict], only updates the conflicting rows matching the predicate,
+// generating ON CONFLICT (keys ...) DO UPDATE SET ... WHERE ....
+//
+// See SelectBuilder.Where for the accepted predicates.
+func (b InsertBuilder) UpdateWhere(pred interface{}, args ...interface{}) InsertBuilder {
+	b.data.UpdateWhereParts = appendCopy(b.data.UpdateWhereParts, newWherePart(pred, args...))
+	return b
+}
+
+// UpdateAllExcept, when used with [InsertBuilder.OnConflict], updates every inserted column with its inserted value,
+// except the conflict keys and the given columns. Columns set by [InsertBuilder.UpdateColumns] or [InsertBuilder.UpdateSet]
+// aren't repeated.
+//
+// The inserted columns are resolved when the query is built, so UpdateAllExcept can be called before [InsertBuilder.Columns]
+// or [InsertBuilder.Struct].
+func (b InsertBuilder) UpdateAllExcept(columns ...string) InsertBuilder {
+	b.data.UpdateAll = true
+	b.data.UpdateExcept = appendCopy(b.data.UpdateExcept, columns...)
+	return b
+}
+
+// Returning adds a RETURNING <columns> suffix (before the [InsertBuilder.Suffix]) to the insert builder,
+// or an OUTPUT clause for dialects that use one instead.
 func (b InsertBuilder) Returning(columns ...string) InsertBuilder {
-	return builder.Extend(b, "Returning", columns).(InsertBuilder)
+	b.data.Returning = appendCopy(b.data.Returning, columns...)
+	return b
 }
 
 // Select set Select clause for insert query
 // If Values and Select are used, then Select has higher priority
-func (b InsertBuilder) Select(sb SelectBuilder) InsertBuilder {
-	return builder.Set(b, "Select", &sb).(InsertBuilder)
+func (b InsertBuilder) Select(sb Subquery) InsertBuilder {
+	b.data.Select = sb
+	return b
 }
 
 func (b InsertBuilder) statementKeyword(keyword string) InsertBuilder {
-	return builder.Set(b, "StatementKeyword", keyword).(InsertBuilder)
+	b.data.StatementKeyword = keyword
+	return b
 }
 
 // StructValues sets values for an insert builder based on the columns already set and the "sq" tag on the struct's value.
@@ -313,20 +489,19 @@ func (b InsertBuilder) StructValues(data interface{}) InsertBuilder {
 		return b
 	}
 
-	mapper := getMapper()
-	lookup := mapper.FieldMap(reflect.ValueOf(data))
+	mapper := mapperOrDefault(b.data.Mapper)
+	value := reflect.ValueOf(data)
 
-	rawColumns, _ := builder.Get(b, "Columns")
-	columns := rawColumns.([]string)
+	columns := b.data.Columns
 
 	values := make([]interface{}, len(columns))
 	for idx, columnName := range columns {
-		value, hasValue := lookup[columnName]
+		field, hasValue := mapper.fieldByColumn(value, columnName)
 		if !hasValue {
-			panic(fmt.Errorf("missing column `%[1]s` in struct. Is it tagged with `sq:\"%[1]s\"`?", columnName))
+			panic(mapper.missingColumnError(columnName))
 		}
 
-		values[idx] = value.Interface()
+		values[idx] = fieldInterface(field)
 	}
 
 	return b.Values(values...)
@@ -351,18 +526,16 @@ func (b InsertBuilder) Struct(data interface{}) InsertBuilder {
 		return b
 	}
 
-	mapper := reflectx.NewMapper("sq")
-	lookup := mapper.FieldMap(reflect.ValueOf(data))
-
-	columns := make([]string, len(lookup))
-	values := make([]interface{}, len(lookup))
+	mapper := mapperOrDefault(b.data.Mapper)
+	value := reflect.Indirect(reflect.ValueOf(data))
+	fields := columnFields(mapper.typeMap(value.Type()))
 
-	idx := 0
-	for column, value := range lookup {
-		columns[idx] = column
-		values[idx] = value.Interface()
+	columns := make([]string, len(fields))
+	values := make([]interface{}, len(fields))
 
-		idx += 1
+	for idx, field := range fields {
+		columns[idx] = field.Path
+		values[idx] = fieldInterface(fieldByIndexesNilable(value, field.Index))
 	}
 
 	return b.Columns(columns...).Values(values...)
@@ -128,6 +128,106 @@ func TestInsertBuilderOnConflictUpdateColumns(t *testing.T) {
 	}
 }
 
+func TestInsertBuilderUpsertClauses(t *testing.T) {
+	type testCase struct {
+		name         string
+		builder      InsertBuilder
+		expectedSql  string
+		expectedArgs []interface{}
+		expectedErr  bool
+	}
+
+	base := Insert("counters").Columns("name", "kind", "counter").Values("visits", "page", 1)
+
+	cases := []testCase{
+		{
+			name:         "on constraint",
+			builder:      base.OnConflictConstraint("counters_pkey").UpdateColumns("counter"),
+			expectedSql:  "INSERT INTO counters (name,kind,counter) VALUES (?,?,?) ON CONFLICT ON CONSTRAINT counters_pkey DO UPDATE SET counter = EXCLUDED.counter",
+			expectedArgs: []interface{}{"visits", "page", 1},
+		},
+		{
+			name:         "partial index",
+			builder:      base.OnConflict("name").OnConflictWhere("kind = ?", "page").DoNothing(),
+			expectedSql:  "INSERT INTO counters (name,kind,counter) VALUES (?,?,?) ON CONFLICT (name) WHERE kind = ? DO NOTHING",
+			expectedArgs: []interface{}{"visits", "page", 1, "page"},
+		},
+		{
+			name: "set expressions",
+			builder: base.OnConflict("name").
+				UpdateColumns("kind").
+				UpdateSet("counter", Expr("counters.counter + EXCLUDED.counter")).
+				UpdateSet("updated", 10),
+			expectedSql:  "INSERT INTO counters (name,kind,counter) VALUES (?,?,?) ON CONFLICT (name) DO UPDATE SET kind = EXCLUDED.kind, counter = counters.counter + EXCLUDED.counter, updated = ?",
+			expectedArgs: []interface{}{"visits", "page", 1, 10},
+		},
+		{
+			name: "update where",
+			builder: base.OnConflict("name").
+				OnConflictWhere(Eq{"kind": "page"}).
+				UpdateSet("counter", Expr("counters.counter + ?", 1)).
+				UpdateWhere(Lt{"counters.counter": 100}).
+				Suffix("RETURNING counter"),
+			expectedSql:  "INSERT INTO counters (name,kind,counter) VALUES (?,?,?) ON CONFLICT (name) WHERE kind = ? DO UPDATE SET counter = counters.counter + ? WHERE counters.counter < ? RETURNING counter",
+			expectedArgs: []interface{}{"visits", "page", 1, "page", 1, 100},
+		},
+		{
+			name: "set subqueries",
+			builder: base.OnConflict("name").
+				UpdateSet("kind", Select("kind").From("kinds").Where("id = ?", 2)).
+				UpdateSet("counter", Union(Select("max(counter)").From("a"), Select("max(counter)").From("b"))),
+			expectedSql:  "INSERT INTO counters (name,kind,counter) VALUES (?,?,?) ON CONFLICT (name) DO UPDATE SET kind = (SELECT kind FROM kinds WHERE id = ?), counter = (SELECT max(counter) FROM a UNION SELECT max(counter) FROM b)",
+			expectedArgs: []interface{}{"visits", "page", 1, 2},
+		},
+		{
+			name:         "update all except",
+			builder:      Insert("t").UpdateAllExcept("created").OnConflict("id").Columns("id", "a", "created", "b").Values(1, 2, 3, 4),
+			expectedSql:  "INSERT INTO t (id,a,created,b) VALUES (?,?,?,?) ON CONFLICT (id) DO UPDATE SET a = EXCLUDED.a, b = EXCLUDED.b",
+			expectedArgs: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name:         "update all except with sets",
+			builder:      Insert("t").Columns("id", "a", "b").Values(1, 2, 3).OnConflict("id").UpdateSet("b", Expr("t.b + 1")).UpdateAllExcept(),
+			expectedSql:  "INSERT INTO t (id,a,b) VALUES (?,?,?) ON CONFLICT (id) DO UPDATE SET a = EXCLUDED.a, b = t.b + 1",
+			expectedArgs: []interface{}{1, 2, 3},
+		},
+		{
+			name:        "update all except without columns",
+			builder:     Insert("t").Values(1, 2).OnConflict("id").UpdateAllExcept(),
+			expectedErr: true,
+		},
+		{
+			name:        "constraint and keys",
+			builder:     base.OnConflict("name").OnConflictConstraint("counters_pkey").DoNothing(),
+			expectedErr: true,
+		},
+		{
+			name:        "conflict where without keys",
+			builder:     base.OnConflictWhere("kind = ?", "page").DoNothing(),
+			expectedErr: true,
+		},
+		{
+			name:        "update where with do nothing",
+			builder:     base.OnConflict("name").UpdateWhere("kind = ?", "page").DoNothing(),
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql, args, err := c.builder.ToSql()
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedSql, sql)
+			assert.Equal(t, c.expectedArgs, args)
+		})
+	}
+}
+
 func TestInsertStructValues(t *testing.T) {
 	record := struct {
 		Pk      int    `sq:"pk"`
@@ -199,3 +299,15 @@ func TestInsertReturning(t *testing.T) {
 	expectedSQL := "INSERT INTO a (b,c) VALUES (?,?) RETURNING pk"
 	assert.Equal(t, expectedSQL, sql)
 }
+
+func BenchmarkInsertBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Insert("t").
+			Columns("a", "b", "c").
+			Values(i, "b", "c").
+			OnConflict("a").
+			UpdateColumns("b", "c").
+			ToSql()
+	}
+}
@@ -0,0 +1,87 @@
+package squirrelly
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// joinExpr is a join clause with a structured target and condition, rendered
+// as "<kind> <target> ON <on>" or "<kind> <target> USING (<using>)".
+type joinExpr struct {
+	kind   string
+	target Sqlizer
+	on     Sqlizer
+	using  []string
+}
+
+func (j joinExpr) ToSql() (string, []interface{}, error) {
+	return j.toSqlDialect(nil)
+}
+
+func (j joinExpr) toSqlDialect(d Dialect) (sqlStr string, args []interface{}, err error) {
+	if j.on == nil && len(j.using) == 0 && !strings.HasPrefix(j.kind, "CROSS ") {
+		err = errors.New("join clauses must have an ON condition or USING columns")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+	sql.WriteString(j.kind)
+	sql.WriteString(" ")
+
+	args, err = appendToSql([]Sqlizer{j.target}, d, sql, "", args)
+	if err != nil {
+		return
+	}
+
+	if j.on != nil {
+		var onSql string
+		var onArgs []interface{}
+		onSql, onArgs, err = nestedToSql(j.on, d)
+		if err != nil {
+			return
+		}
+		sql.WriteString(" ON ")
+		sql.WriteString(onSql)
+		args = append(args, onArgs...)
+	}
+
+	if len(j.using) > 0 {
+		sql.WriteString(" USING (")
+		sql.WriteString(strings.Join(j.using, ", "))
+		sql.WriteString(")")
+	}
+
+	sqlStr = sql.String()
+	return
+}
+
+// appendTablesToSql writes the comma separated tables of a multi-table update
+// or delete, followed by its joins.
+func appendTablesToSql(tables, joins []Sqlizer, d Dialect, w io.Writer, args []interface{}) ([]interface{}, error) {
+	args, err := appendToSql(tables, d, w, ", ", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(joins) > 0 {
+		if len(tables) > 0 {
+			if _, err := io.WriteString(w, " "); err != nil {
+				return nil, err
+			}
+		}
+		return appendToSql(joins, d, w, " ", args)
+	}
+	return args, nil
+}
+
+// tableAlias returns the name a table expression such as "users u" or
+// "users AS u" is referred to by.
+func tableAlias(table string) string {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return table
+	}
+	return fields[len(fields)-1]
+}
@@ -0,0 +1,59 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectBuilderStructuredJoins(t *testing.T) {
+	counts := Select("author_id", "count(*) AS n").From("comments").Where("spam = ?", false).GroupBy("author_id")
+	latest := Select("body").From("posts lp").Where("lp.author_id = u.id AND lp.draft = ?", false).OrderBy("lp.id DESC").Limit(1)
+
+	sql, args, err := Select("u.id", "c.n", "l.body").
+		From("users u").
+		JoinOn("profiles p", And{Expr("p.user_id = u.id"), Eq{"p.public": true}}).
+		LeftJoinOn("teams t", Expr("t.id = u.team_id AND t.kind = ?", "staff")).
+		JoinUsing("settings", "user_id", "org_id").
+		JoinSelect(counts, "c", Expr("c.author_id = u.id")).
+		LeftJoinSelect(counts.Where("n > ?", 10), "h", Expr("h.author_id = u.id")).
+		LateralJoin(latest, "l", nil).
+		FullJoin("audits a ON a.user_id = u.id AND a.level > ?", 2).
+		Where("u.active = ?", true).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT u.id, c.n, l.body FROM users u " +
+		"JOIN profiles p ON (p.user_id = u.id AND p.public = $1) " +
+		"LEFT JOIN teams t ON t.id = u.team_id AND t.kind = $2 " +
+		"JOIN settings USING (user_id, org_id) " +
+		"JOIN (SELECT author_id, count(*) AS n FROM comments WHERE spam = $3 GROUP BY author_id) AS c ON c.author_id = u.id " +
+		"LEFT JOIN (SELECT author_id, count(*) AS n FROM comments WHERE spam = $4 AND n > $5 GROUP BY author_id) AS h ON h.author_id = u.id " +
+		"CROSS JOIN LATERAL (SELECT body FROM posts lp WHERE lp.author_id = u.id AND lp.draft = $6 ORDER BY lp.id DESC LIMIT 1) AS l " +
+		"FULL JOIN audits a ON a.user_id = u.id AND a.level > $7 " +
+		"WHERE u.active = $8"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{true, "staff", false, false, 10, false, 2, true}, args)
+}
+
+func TestSelectBuilderLateralJoinOn(t *testing.T) {
+	sql, args, err := Select("*").
+		From("a").
+		LateralJoin(Select("x").From("b").Where("b.a_id = a.id"), "b", Expr("b.x > ?", 1)).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM a JOIN LATERAL (SELECT x FROM b WHERE b.a_id = a.id) AS b ON b.x > ?", sql)
+	assert.Equal(t, []interface{}{1}, args)
+}
+
+func TestSelectBuilderStructuredJoinErr(t *testing.T) {
+	_, _, err := Select("*").From("a").JoinOn("b", nil).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Select("*").From("a").JoinUsing("b").ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Select("*").From("a").JoinSelect(Select(), "b", Expr("true")).ToSql()
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package squirrelly
+
+import (
+	"strings"
+)
+
+// SqlSyntax describes the parts of a database's SQL that placeholders can't
+// appear in, which placeholder rewriting and DebugSqlizer leave alone. Single
+// and double quoted strings and identifiers, and -- and /* */ comments, are
+// always recognised.
+type SqlSyntax struct {
+	// DollarQuotes recognises Postgres dollar quoted strings, such as
+	// $$...$$ and $tag$...$tag$.
+	DollarQuotes bool
+
+	// BackslashEscapes recognises quotes escaped with a backslash inside
+	// quoted strings, as in MySQL.
+	BackslashEscapes bool
+
+	// BacktickQuotes recognises identifiers quoted with backticks, as in MySQL
+	// and sqlite.
+	BacktickQuotes bool
+
+	// BracketQuotes recognises identifiers quoted with square brackets, as in
+	// SQL Server and sqlite.
+	BracketQuotes bool
+
+	// HashComments recognises comments starting with #, as in MySQL.
+	HashComments bool
+
+	// JsonOperators recognises the ?| and ?& jsonb operators of Postgres,
+	// which are left in place rather than read as a placeholder.
+	JsonOperators bool
+}
+
+// sqlToken is a part of a SQL statement, either code that placeholders may
+// appear in, or a string, quoted identifier or comment.
+type sqlToken struct {
+	text string
+	code bool
+}
+
+// tokenize splits sql into code and the strings, quoted identifiers and
+// comments between it. Joining the tokens' text gives back sql.
+func (s SqlSyntax) tokenize(sql string) []sqlToken {
+	var tokens []sqlToken
+
+	start := 0
+	for i := 0; i < len(sql); {
+		end := s.literalEnd(sql, i)
+		if end == i {
+			i++
+			continue
+		}
+
+		if start < i {
+			tokens = append(tokens, sqlToken{text: sql[start:i], code: true})
+		}
+		tokens = append(tokens, sqlToken{text: sql[i:end]})
+		i, start = end, end
+	}
+
+	if start < len(sql) {
+		tokens = append(tokens, sqlToken{text: sql[start:], code: true})
+	}
+	return tokens
+}
+
+// literalEnd returns the end of the string, quoted identifier or comment that
+// starts at sql[i], or i if none does. Unterminated ones end with sql.
+func (s SqlSyntax) literalEnd(sql string, i int) int {
+	switch c := sql[i]; {
+	case c == '\'' || c == '"':
+		return quotedEnd(sql, i, c, s.BackslashEscapes)
+	case c == '`' && s.BacktickQuotes:
+		return quotedEnd(sql, i, '`', false)
+	case c == '[' && s.BracketQuotes:
+		return quotedEnd(sql, i, ']', false)
+	case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#' && s.HashComments:
+		if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(sql)
+	case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+		if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(sql)
+	case c == '$' && s.DollarQuotes:
+		return dollarQuotedEnd(sql, i)
+	}
+	return i
+}
+
+// quotedEnd returns the end of the quoted string or identifier starting at
+// sql[i], which is closed by close. A doubled close doesn't close it, and
+// neither does an escaped one if backslash is set.
+func quotedEnd(sql string, i int, close byte, backslash bool) int {
+	for j := i + 1; j < len(sql); j++ {
+		switch {
+		case backslash && sql[j] == '\\':
+			j++
+		case sql[j] == close:
+			if j+1 < len(sql) && sql[j+1] == close {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(sql)
+}
+
+// dollarQuotedEnd returns the end of the dollar quoted string starting at
+// sql[i], or i if sql[i] doesn't start one, as with $1 placeholders or the $
+// inside identifiers.
+func dollarQuotedEnd(sql string, i int) int {
+	if i > 0 && isIdentByte(sql[i-1]) {
+		return i
+	}
+
+	j := i + 1
+	for j < len(sql) && sql[j] != '$' && isIdentByte(sql[j]) && (j > i+1 || !isDigit(sql[j])) {
+		j++
+	}
+	if j >= len(sql) || sql[j] != '$' {
+		return i
+	}
+
+	delim := sql[i : j+1]
+	if end := strings.Index(sql[j+1:], delim); end >= 0 {
+		return j + 1 + end + len(delim)
+	}
+	return len(sql)
+}
+
+// unescapeLiteral returns the text of a string, quoted identifier or comment
+// with each escaped placeholder, ??, written as a single question mark. A lone
+// question mark there isn't a placeholder, but ?? was unescaped everywhere
+// before placeholders were only looked for in code, so it still is.
+func unescapeLiteral(text string) string {
+	return strings.ReplaceAll(text, "??", "?")
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scanPlaceholders writes code to buf, calling placeholder in place of each
+// question mark placeholder. Escaped placeholders, ??, are written as a single
+// question mark, and jsonb operators are written as they are if the syntax
+// has them.
+func (s SqlSyntax) scanPlaceholders(code string, buf *strings.Builder, placeholder func()) {
+	for {
+		p := strings.IndexByte(code, '?')
+		if p == -1 {
+			break
+		}
+		buf.WriteString(code[:p])
+		rest := code[p+1:]
+
+		switch {
+		case strings.HasPrefix(rest, "?"): // escape ?? => ?
+			buf.WriteString("?")
+			rest = rest[1:]
+		case s.JsonOperators && strings.HasPrefix(rest, "&"),
+			s.JsonOperators && strings.HasPrefix(rest, "|") && !strings.HasPrefix(rest, "||"):
+			buf.WriteString(code[p : p+2])
+			rest = rest[1:]
+		default:
+			placeholder()
+		}
+		code = rest
+	}
+	buf.WriteString(code)
+}
@@ -0,0 +1,97 @@
+package squirrelly
+
+import (
+	"strings"
+)
+
+// likeEscape is the escape character of the patterns built by LikeExpr. It's
+// not a backslash, which some databases also treat as an escape in string
+// literals.
+const likeEscape = "!"
+
+// likeEscaper escapes the wildcards of LIKE patterns, along with the escape
+// character itself and SQL Server's character class bracket.
+var likeEscaper = strings.NewReplacer(
+	likeEscape, likeEscape+likeEscape,
+	"%", likeEscape+"%",
+	"_", likeEscape+"_",
+	"[", likeEscape+"[",
+)
+
+// LikeExpr is a LIKE predicate matching a column against a literal value,
+// built by Contains, StartsWith, EndsWith and their case insensitive variants.
+//
+// Unlike Like, wildcards in the value are escaped, so user input only matches
+// itself, and the predicate renders an ESCAPE clause:
+//
+//	.Where(Contains("name", "50%")) == "name LIKE ? ESCAPE '!'" with "%50!%%"
+type LikeExpr struct {
+	column          string
+	pattern         string
+	caseInsensitive bool
+}
+
+func newLikeExpr(column, prefix, value, suffix string, caseInsensitive bool) LikeExpr {
+	return LikeExpr{
+		column:          column,
+		pattern:         prefix + likeEscaper.Replace(value) + suffix,
+		caseInsensitive: caseInsensitive,
+	}
+}
+
+// Contains matches rows whose column contains value.
+func Contains(column, value string) LikeExpr {
+	return newLikeExpr(column, "%", value, "%", false)
+}
+
+// StartsWith matches rows whose column starts with value.
+func StartsWith(column, value string) LikeExpr {
+	return newLikeExpr(column, "", value, "%", false)
+}
+
+// EndsWith matches rows whose column ends with value.
+func EndsWith(column, value string) LikeExpr {
+	return newLikeExpr(column, "%", value, "", false)
+}
+
+// IContains matches rows whose column contains value, ignoring case.
+//
+// Case insensitive matches render ILIKE, or LOWER(column) LIKE LOWER(?) if the
+// Dialect of the statement has no ILIKE, as with Sqlite and MySql.
+func IContains(column, value string) LikeExpr {
+	return newLikeExpr(column, "%", value, "%", true)
+}
+
+// IStartsWith matches rows whose column starts with value, ignoring case.
+//
+// See IContains for how case insensitive matches are rendered.
+func IStartsWith(column, value string) LikeExpr {
+	return newLikeExpr(column, "", value, "%", true)
+}
+
+// IEndsWith matches rows whose column ends with value, ignoring case.
+//
+// See IContains for how case insensitive matches are rendered.
+func IEndsWith(column, value string) LikeExpr {
+	return newLikeExpr(column, "%", value, "", true)
+}
+
+func (e LikeExpr) ToSql() (string, []interface{}, error) {
+	return e.toSqlDialect(nil)
+}
+
+func (e LikeExpr) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	switch {
+	case !e.caseInsensitive:
+		sql, args, err = Like{e.column: e.pattern}.ToSql()
+	case dialectOrDefault(d).ILike():
+		sql, args, err = ILike{e.column: e.pattern}.ToSql()
+	default:
+		sql, args, err = Like{"LOWER(" + e.column + ")": Expr("LOWER(?)", e.pattern)}.ToSql()
+	}
+
+	if err == nil {
+		sql += " ESCAPE '" + likeEscape + "'"
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLikeExprToSql(t *testing.T) {
+	type testCase struct {
+		pred            Sqlizer
+		dialect         Dialect
+		expectedSql     string
+		expectedPattern string
+	}
+
+	cases := []testCase{
+		{Contains("name", "50%"), nil, "name LIKE ? ESCAPE '!'", "%50!%%"},
+		{StartsWith("name", "a_b"), nil, "name LIKE ? ESCAPE '!'", "a!_b%"},
+		{EndsWith("name", "wow!"), nil, "name LIKE ? ESCAPE '!'", "%wow!!"},
+		{Contains("name", "[x]"), nil, "name LIKE ? ESCAPE '!'", "%![x]%"},
+		{IContains("name", "Ab"), nil, "name ILIKE ? ESCAPE '!'", "%Ab%"},
+		{IStartsWith("name", "Ab"), Postgres, "name ILIKE ? ESCAPE '!'", "Ab%"},
+		{IEndsWith("name", "_%"), Sqlite, "LOWER(name) LIKE LOWER(?) ESCAPE '!'", "%!_!%"},
+		{IContains("name", "Ab"), MySql, "LOWER(name) LIKE LOWER(?) ESCAPE '!'", "%Ab%"},
+		{StartsWith("name", "Ab"), MySql, "name LIKE ? ESCAPE '!'", "Ab%"},
+		{Not(IContains("name", "x")), SqlServer, "NOT (LOWER(name) LIKE LOWER(?) ESCAPE '!')", "%x%"},
+		{Not(Contains("name", "x")), nil, "NOT (name LIKE ? ESCAPE '!')", "%x%"},
+	}
+
+	for _, c := range cases {
+		sql, args, err := nestedToSql(c.pred, c.dialect)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+		assert.Equal(t, []interface{}{c.expectedPattern}, args)
+	}
+}
+
+func TestLikeExprInBuilders(t *testing.T) {
+	sql, args, err := Select("id").
+		From("users").
+		Where(Or{IContains("name", "a%"), IStartsWith("email", "b")}).
+		Dialect(MySql).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM users WHERE (LOWER(name) LIKE LOWER(?) ESCAPE '!' OR LOWER(email) LIKE LOWER(?) ESCAPE '!')", sql)
+	assert.Equal(t, []interface{}{"%a!%%", "b%"}, args)
+
+	sql, _, err = Select("id").From("users").Where(IContains("name", "a")).Dialect(Postgres).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM users WHERE name ILIKE $1 ESCAPE '!'", sql)
+}
@@ -0,0 +1,277 @@
+package squirrelly
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// DefaultTagName is the struct tag used to map fields to columns, unless a Mapper is configured otherwise.
+const DefaultTagName = "sq"
+
+// Mapper maps struct fields to column names. It's used when scanning query
+// results into structs, and by builder methods that read structs, such as
+// [InsertBuilder.Struct], [InsertBuilder.StructValues] and
+// [UpdateBuilder.SetStruct].
+//
+// Fields are named by their struct tag (`sq` by default), and fields without a
+// tag are named by their Go name, optionally transformed by a name mapping
+// function. A Mapper is safe for concurrent use.
+type Mapper struct {
+	mapper          *reflectx.Mapper
+	tagName         string
+	caseInsensitive bool
+
+	// folded caches case folded field names, for case insensitive column matching
+	folded sync.Map
+
+	// plans caches compiled scan plans, see scanPlan. It's replaced by an
+	// empty cache once full.
+	plans atomic.Pointer[scanPlanCache]
+}
+
+type mapperOptions struct {
+	tagName         string
+	nameFunc        func(string) string
+	caseInsensitive bool
+}
+
+// MapperOption configures a Mapper created by [NewMapper].
+type MapperOption func(*mapperOptions)
+
+// WithTagName sets the struct tag used to name columns, replacing the default `sq` tag.
+func WithTagName(tagName string) MapperOption {
+	return func(o *mapperOptions) {
+		o.tagName = tagName
+	}
+}
+
+// WithNameMapper sets a function used to name the columns of fields that aren't tagged, for example [SnakeCase] or [strings.ToLower].
+// By default untagged fields are named by their exact Go name.
+func WithNameMapper(fn func(string) string) MapperOption {
+	return func(o *mapperOptions) {
+		o.nameFunc = fn
+	}
+}
+
+// WithCaseInsensitiveColumns makes the mapper match result columns and column names to fields ignoring case,
+// which helps with drivers that upper-case column names. Exact matches are still preferred.
+func WithCaseInsensitiveColumns() MapperOption {
+	return func(o *mapperOptions) {
+		o.caseInsensitive = true
+	}
+}
+
+// NewMapper creates a new Mapper. Without options it behaves the same as the default mapper,
+// naming fields by their `sq` tag, or by their Go name if they aren't tagged.
+func NewMapper(options ...MapperOption) *Mapper {
+	opts := mapperOptions{tagName: DefaultTagName}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	m := &Mapper{
+		mapper:          reflectx.NewMapperFunc(opts.tagName, opts.nameFunc),
+		tagName:         opts.tagName,
+		caseInsensitive: opts.caseInsensitive,
+	}
+	m.plans.Store(&scanPlanCache{})
+	return m
+}
+
+var defaultMapper atomic.Pointer[Mapper]
+
+func init() {
+	defaultMapper.Store(NewMapper())
+}
+
+// SetDefaultMapper replaces the package level mapper, used by builders and Dbs that don't have their own mapper.
+// It's safe to call concurrently with queries, which use whichever mapper was the default when they started.
+func SetDefaultMapper(m *Mapper) {
+	if m == nil {
+		m = NewMapper()
+	}
+	defaultMapper.Store(m)
+}
+
+func getMapper() *Mapper {
+	return defaultMapper.Load()
+}
+
+// mapperOrDefault returns m, or the package level mapper if m isn't set.
+func mapperOrDefault(m *Mapper) *Mapper {
+	if m != nil {
+		return m
+	}
+	return getMapper()
+}
+
+// typeMap returns the field mapping for a struct type, or a pointer to a struct type.
+func (m *Mapper) typeMap(t reflect.Type) *reflectx.StructMap {
+	return m.mapper.TypeMap(reflectx.Deref(t))
+}
+
+// field looks up the field mapped to a column name.
+func (m *Mapper) field(typeMap *reflectx.StructMap, column string) (*reflectx.FieldInfo, bool) {
+	field, ok := typeMap.Names[column]
+	if ok || !m.caseInsensitive {
+		return field, ok
+	}
+
+	var folded map[string]*reflectx.FieldInfo
+	if cached, ok := m.folded.Load(typeMap); ok {
+		folded = cached.(map[string]*reflectx.FieldInfo)
+	} else {
+		folded = make(map[string]*reflectx.FieldInfo, len(typeMap.Names))
+		for _, fi := range typeMap.Index {
+			key := strings.ToLower(fi.Path)
+			if _, exists := folded[key]; !exists && typeMap.Names[fi.Path] == fi {
+				folded[key] = fi
+			}
+		}
+		m.folded.Store(typeMap, folded)
+	}
+
+	field, ok = folded[strings.ToLower(column)]
+	return field, ok
+}
+
+// traversalsByName returns the field index of each column, or an empty index if the column isn't mapped.
+func (m *Mapper) traversalsByName(t reflect.Type, columns []string) [][]int {
+	typeMap := m.typeMap(t)
+
+	traversals := make([][]int, len(columns))
+	for idx, column := range columns {
+		if field, ok := m.field(typeMap, column); ok {
+			traversals[idx] = field.Index
+		} else {
+			traversals[idx] = []int{}
+		}
+	}
+	return traversals
+}
+
+// fieldByColumn returns the value of the field mapped to column.
+// The value is invalid if reaching the field traverses a nil pointer.
+func (m *Mapper) fieldByColumn(v reflect.Value, column string) (reflect.Value, bool) {
+	v = reflect.Indirect(v)
+	field, ok := m.field(m.typeMap(v.Type()), column)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	return fieldByIndexesNilable(v, field.Index), true
+}
+
+// columnsMatch reports whether two column names refer to the same column.
+func (m *Mapper) columnsMatch(a, b string) bool {
+	if m.caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func (m *Mapper) missingColumnError(column string) error {
+	return fmt.Errorf("missing column `%[1]s` in struct. Is it tagged with `%[2]s:\"%[1]s\"`?", column, m.tagName)
+}
+
+// fieldInterface returns the value of a field as an interface, treating invalid values as nil.
+func fieldInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// SnakeCase converts a Go field name into a snake_case column name, keeping initialisms together.
+// For example "UserID" becomes "user_id" and "HTTPServer" becomes "http_server".
+func SnakeCase(name string) string {
+	runes := []rune(name)
+
+	buf := &strings.Builder{}
+	for idx, r := range runes {
+		if unicode.IsUpper(r) {
+			if idx > 0 {
+				prev := runes[idx-1]
+				nextIsLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+					buf.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+var (
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// isLeafField reports whether a mapped field holds a single column value, rather than a struct of other columns.
+func isLeafField(field *reflectx.FieldInfo) bool {
+	// fields nested inside a valuer (e.g. sql.NullString.Valid) belong to their parent
+	for parent := field.Parent; parent != nil && parent.Parent != nil; parent = parent.Parent {
+		if isAtomicType(parent.Field.Type) {
+			return false
+		}
+	}
+
+	return len(field.Children) == 0 || isAtomicType(field.Field.Type)
+}
+
+// isAtomicType reports whether values of typ are passed to the driver as a single value, even if typ is a struct.
+func isAtomicType(typ reflect.Type) bool {
+	if typ.Implements(valuerType) || reflect.PointerTo(typ).Implements(valuerType) {
+		return true
+	}
+
+	return reflectx.Deref(typ) == timeType
+}
+
+// fieldByIndexesNilable works like [reflectx.FieldByIndexesReadOnly], but returns an invalid value instead of panicking when it traverses a nil pointer.
+func fieldByIndexesNilable(v reflect.Value, indexes []int) reflect.Value {
+	for _, i := range indexes {
+		v = reflect.Indirect(v)
+		if !v.IsValid() {
+			return v
+		}
+
+		v = v.Field(i)
+	}
+	return v
+}
+
+// columnFields returns the fields of a struct mapping that hold column values, in declaration order.
+// Fields of nested structs are listed in place of the struct itself.
+func columnFields(typeMap *reflectx.StructMap) []*reflectx.FieldInfo {
+	fields := []*reflectx.FieldInfo{}
+
+	var walk func(*reflectx.FieldInfo)
+	walk = func(parent *reflectx.FieldInfo) {
+		for _, field := range parent.Children {
+			if field == nil {
+				continue
+			}
+
+			if !field.Embedded && typeMap.Names[field.Path] == field && isLeafField(field) {
+				fields = append(fields, field)
+			} else if !isAtomicType(field.Field.Type) {
+				walk(field)
+			}
+		}
+	}
+	walk(typeMap.Tree)
+
+	return fields
+}
@@ -0,0 +1,177 @@
+package squirrelly
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Id":         "id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"CreatedAt":  "created_at",
+		"Address2":   "address2",
+		"Line2Text":  "line2_text",
+		"already":    "already",
+	}
+
+	for name, expected := range cases {
+		assert.Equal(t, expected, SnakeCase(name), name)
+	}
+}
+
+type mapperRecord struct {
+	UserID    int `db:"id"`
+	FirstName string
+	Ignored   string `db:"-"`
+}
+
+func TestMapperOptions(t *testing.T) {
+	mapper := NewMapper(WithTagName("db"), WithNameMapper(SnakeCase))
+
+	typ := reflect.TypeOf(mapperRecord{})
+	assert.Equal(t, [][]int{{0}, {1}, {}}, mapper.traversalsByName(typ, []string{"id", "first_name", "FIRST_NAME"}))
+
+	insensitive := NewMapper(WithTagName("db"), WithNameMapper(SnakeCase), WithCaseInsensitiveColumns())
+	assert.Equal(t, [][]int{{0}, {1}, {}}, insensitive.traversalsByName(typ, []string{"ID", "First_Name", "ignored"}))
+
+	record := mapperRecord{UserID: 1, FirstName: "ada"}
+	field, ok := insensitive.fieldByColumn(reflect.ValueOf(&record), "FIRST_NAME")
+	assert.True(t, ok)
+	assert.Equal(t, "ada", field.Interface())
+
+	assert.EqualError(t, mapper.missingColumnError("foo"), "missing column `foo` in struct. Is it tagged with `db:\"foo\"`?")
+}
+
+func TestSetDefaultMapper(t *testing.T) {
+	defer SetDefaultMapper(NewMapper())
+	SetDefaultMapper(NewMapper(WithNameMapper(SnakeCase)))
+
+	record := struct {
+		UserID    int
+		FirstName string
+		Comment   string `sq:"note"`
+	}{UserID: 1, FirstName: "ada", Comment: "hi"}
+
+	sql, args, err := Insert("users").Struct(&record).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (user_id,first_name,note) VALUES (?,?,?)", sql)
+	assert.Equal(t, []interface{}{1, "ada", "hi"}, args)
+
+	sql, _, err = Insert("users").Columns("first_name").StructValues(&record).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (first_name) VALUES (?)", sql)
+
+	sql, args, err = Update("users").SetStruct(record, "first_name", "note").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE users SET first_name = ?, note = ?", sql)
+	assert.Equal(t, []interface{}{"ada", "hi"}, args)
+}
+
+func TestStatementBuilderMapper(t *testing.T) {
+	record := struct {
+		UserID int `db:"id"`
+		Name   string
+	}{UserID: 1, Name: "ada"}
+
+	sb := StatementBuilder.Mapper(NewMapper(WithTagName("db"), WithNameMapper(SnakeCase)))
+
+	sql, _, err := sb.Insert("users").Struct(&record).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (id,name) VALUES (?,?)", sql)
+
+	sql, _, err = sb.Update("users").SetStruct(&record, "name").WhereStruct(&record).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE users SET name = ? WHERE (id = ? AND name = ?)", sql)
+
+	sql, _, err = sb.Select().ColumnsFor(record, "").From("users").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM users", sql)
+
+	// builders without a mapper use the package level default
+	sql, _, err = Select().ColumnsFor(record, "").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT UserID, Name", sql)
+
+	sql, _, err = Select().Mapper(nil).ColumnsFor(record, "").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT UserID, Name", sql)
+}
+
+func TestMapperConcurrency(t *testing.T) {
+	defer SetDefaultMapper(nil)
+
+	type record struct {
+		UserID    int
+		FirstName string `sq:"first"`
+		LastName  string
+	}
+
+	type result struct {
+		traversals [][]int
+		plan       [][]int
+		insertSql  string
+		columnsSql string
+	}
+
+	newMappers := func() []*Mapper {
+		return []*Mapper{
+			NewMapper(),
+			NewMapper(WithNameMapper(SnakeCase)),
+			NewMapper(WithTagName("db"), WithCaseInsensitiveColumns()),
+		}
+	}
+
+	typ := reflect.TypeOf(record{})
+	columns := []string{"USERID", "first", "last_name"}
+	run := func(mapper *Mapper) result {
+		insertSql, _ := StatementBuilder.Mapper(mapper).Insert("users").Struct(&record{}).MustSql()
+		columnsSql, _ := StatementBuilder.Mapper(mapper).Select().ColumnsFor(record{}, "u").MustSql()
+		return result{
+			traversals: mapper.traversalsByName(typ, columns),
+			plan:       mapper.scanPlan(typ, columns).fields,
+			insertSql:  insertSql,
+			columnsSql: columnsSql,
+		}
+	}
+
+	// the serial results come from their own mappers, so the concurrent ones
+	// start with empty caches
+	serial := []result{}
+	defaultInserts := []string{}
+	for _, mapper := range newMappers() {
+		serial = append(serial, run(mapper))
+		defaultInserts = append(defaultInserts, serial[len(serial)-1].insertSql)
+	}
+
+	mappers := newMappers()
+	results := make([]result, 50)
+	inserts := make([]string, 50)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			mapper := mappers[i%len(mappers)]
+			if i%10 == 0 {
+				SetDefaultMapper(mapper)
+			}
+
+			results[i] = run(mapper)
+			inserts[i], _ = Insert("users").Struct(&record{}).MustSql()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		assert.Equal(t, serial[i%len(mappers)], result, "goroutine %d", i)
+		// the default mapper is changed concurrently, so may be any of them
+		assert.Contains(t, defaultInserts, inserts[i], "goroutine %d", i)
+	}
+}
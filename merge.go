@@ -0,0 +1,466 @@
+package squirrelly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type mergeData struct {
+	PlaceholderFormat PlaceholderFormat
+	Dialect           Dialect
+	Prefixes          []Sqlizer
+	With              []commonTableExpr
+	Into              string
+	Using             Sqlizer
+	OnParts           []Sqlizer
+	Whens             []mergeWhen
+	Suffixes          []Sqlizer
+
+	// Err is the first misuse of the Then methods, returned by ToSql
+	Err error
+}
+
+type mergeMatch string
+
+const (
+	mergeMatched            mergeMatch = "MATCHED"
+	mergeNotMatched         mergeMatch = "NOT MATCHED"
+	mergeNotMatchedBySource mergeMatch = "NOT MATCHED BY SOURCE"
+)
+
+type mergeAction string
+
+const (
+	mergeActionNone      mergeAction = ""
+	mergeActionUpdate    mergeAction = "UPDATE"
+	mergeActionDelete    mergeAction = "DELETE"
+	mergeActionInsert    mergeAction = "INSERT"
+	mergeActionDoNothing mergeAction = "DO NOTHING"
+)
+
+// mergeWhen is a WHEN [NOT] MATCHED [AND ...] THEN ... branch of a merge statement.
+type mergeWhen struct {
+	match      mergeMatch
+	conditions []Sqlizer
+	action     mergeAction
+	sets       []setClause
+	columns    []string
+	values     []interface{}
+}
+
+func (d *mergeData) ToSql() (sqlStr string, args []interface{}, err error) {
+	if d.Err != nil {
+		err = d.Err
+		return
+	}
+	if len(d.Into) == 0 {
+		err = errors.New("merge statements must specify a target table")
+		return
+	}
+	if d.Using == nil {
+		err = errors.New("merge statements must specify a source with Using or UsingSelect")
+		return
+	}
+	if len(d.OnParts) == 0 {
+		err = errors.New("merge statements must have an On condition")
+		return
+	}
+	if len(d.Whens) == 0 {
+		err = errors.New("merge statements must have at least one WHEN clause")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	if len(d.Prefixes) > 0 {
+		args, err = appendToSql(d.Prefixes, d.Dialect, sql, " ", args)
+		if err != nil {
+			return
+		}
+
+		sql.WriteString(" ")
+	}
+
+	args, err = appendWithToSql(d.With, d.Dialect, sql, args)
+	if err != nil {
+		return
+	}
+
+	sql.WriteString("MERGE INTO ")
+	sql.WriteString(d.Into)
+
+	sql.WriteString(" USING ")
+	args, err = appendToSql([]Sqlizer{d.Using}, d.Dialect, sql, "", args)
+	if err != nil {
+		return
+	}
+
+	sql.WriteString(" ON ")
+	args, err = appendToSql(d.OnParts, d.Dialect, sql, " AND ", args)
+	if err != nil {
+		return
+	}
+
+	for _, when := range d.Whens {
+		sql.WriteString(" ")
+		args, err = when.appendToSql(sql, d.Dialect, args)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(d.Suffixes) > 0 {
+		sql.WriteString(" ")
+		args, err = appendToSql(d.Suffixes, d.Dialect, sql, " ", args)
+		if err != nil {
+			return
+		}
+	}
+
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, d.Dialect, sql.String())
+	return
+}
+
+func (w mergeWhen) appendToSql(sql *bytes.Buffer, d Dialect, args []interface{}) ([]interface{}, error) {
+	sql.WriteString("WHEN ")
+	sql.WriteString(string(w.match))
+
+	var err error
+	if len(w.conditions) > 0 {
+		sql.WriteString(" AND ")
+		args, err = appendToSql(w.conditions, d, sql, " AND ", args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sql.WriteString(" THEN ")
+
+	if w.action == mergeActionNone {
+		return nil, fmt.Errorf("WHEN %s clauses of merge statements must have an action", w.match)
+	}
+	if err = w.validateAction(); err != nil {
+		return nil, err
+	}
+	if err = dialectOrDefault(d).MergeAction(string(w.action)); err != nil {
+		return nil, err
+	}
+
+	switch w.action {
+	case mergeActionUpdate:
+		if len(w.sets) == 0 {
+			return nil, fmt.Errorf("WHEN %s THEN UPDATE clauses must have at least one Set clause", w.match)
+		}
+
+		setSqls := make([]string, len(w.sets))
+		for idx, set := range w.sets {
+			valSql, valArgs, err := operandSql(set.value, d)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, valArgs...)
+			setSqls[idx] = fmt.Sprintf("%s = %s", set.column, valSql)
+		}
+
+		sql.WriteString("UPDATE SET ")
+		sql.WriteString(strings.Join(setSqls, ", "))
+
+	case mergeActionInsert:
+		sql.WriteString("INSERT")
+		if len(w.columns) > 0 {
+			sql.WriteString(" (")
+			sql.WriteString(strings.Join(w.columns, ","))
+			sql.WriteString(")")
+		}
+
+		if len(w.values) == 0 {
+			if len(w.columns) > 0 {
+				return nil, fmt.Errorf("WHEN %s THEN INSERT clauses with columns must have a value for each of them", w.match)
+			}
+			sql.WriteString(" DEFAULT VALUES")
+			break
+		}
+
+		if len(w.columns) > 0 && len(w.columns) != len(w.values) {
+			return nil, fmt.Errorf("WHEN %s THEN INSERT clauses must have a value for each of their %d columns, not %d", w.match, len(w.columns), len(w.values))
+		}
+
+		valueSqls := make([]string, len(w.values))
+		for idx, value := range w.values {
+			valueSql, valueArgs, err := operandSql(value, d)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, valueArgs...)
+			valueSqls[idx] = valueSql
+		}
+
+		sql.WriteString(" VALUES (")
+		sql.WriteString(strings.Join(valueSqls, ","))
+		sql.WriteString(")")
+
+	default:
+		sql.WriteString(string(w.action))
+	}
+
+	return args, nil
+}
+
+// validateAction checks that the branch's action can change the rows it
+// applies to: only source rows without a target row can be inserted, and only
+// target rows can be updated or deleted.
+func (w mergeWhen) validateAction() error {
+	switch {
+	case w.action == mergeActionInsert && w.match != mergeNotMatched:
+		return fmt.Errorf("WHEN %s clauses can't insert rows, only WHEN NOT MATCHED clauses can", w.match)
+	case (w.action == mergeActionUpdate || w.action == mergeActionDelete) && w.match == mergeNotMatched:
+		return fmt.Errorf("WHEN NOT MATCHED clauses can't %s rows, as there is no target row", strings.ToLower(string(w.action)))
+	}
+	return nil
+}
+
+// Builder
+
+// MergeBuilder builds SQL MERGE statements, as supported by Postgres 15 and
+// later, SQL Server and Oracle.
+//
+// Each WHEN branch is started by WhenMatched, WhenNotMatched or
+// WhenNotMatchedBySource, and its action is set by the Then methods called
+// after it:
+//
+//	Merge("accounts a").
+//		UsingSelect(Select("id", "balance", "closed").From("staged_accounts"), "s").
+//		On("a.id = s.id").
+//		WhenMatched(Expr("s.closed")).ThenDelete().
+//		WhenMatched().ThenSet("balance", Expr("s.balance")).
+//		WhenNotMatched().ThenInsert([]string{"id", "balance"}, Expr("s.id"), Expr("s.balance"))
+//
+// SQL Server requires MERGE statements to end with a semicolon, which can be
+// added with Suffix(";").
+type MergeBuilder struct {
+	data mergeData
+}
+
+// Format methods
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b MergeBuilder) PlaceholderFormat(f PlaceholderFormat) MergeBuilder {
+	b.data.PlaceholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect used to render the query, along with its
+// PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b MergeBuilder) Dialect(d Dialect) MergeBuilder {
+	b.data.Dialect = d
+	b.data.PlaceholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// SQL methods
+
+// ToSql builds the query into a SQL string and bound args.
+func (b MergeBuilder) ToSql() (string, []interface{}, error) {
+	return b.data.ToSql()
+}
+
+func (b MergeBuilder) debugFormat() (PlaceholderFormat, Dialect) {
+	return b.data.PlaceholderFormat, b.data.Dialect
+}
+
+// MustSql builds the query into a SQL string and bound args.
+// It panics if there are any errors.
+func (b MergeBuilder) MustSql() (string, []interface{}) {
+	sql, args, err := b.ToSql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Prefix adds an expression to the beginning of the query
+func (b MergeBuilder) Prefix(sql string, args ...interface{}) MergeBuilder {
+	return b.PrefixExpr(Expr(sql, args...))
+}
+
+// PrefixExpr adds an expression to the very beginning of the query
+func (b MergeBuilder) PrefixExpr(expr Sqlizer) MergeBuilder {
+	b.data.Prefixes = appendCopy(b.data.Prefixes, expr)
+	return b
+}
+
+// With adds a common table expression to the WITH clause of the query.
+//
+// See SelectBuilder.With for more information.
+func (b MergeBuilder) With(name string, query Subquery) MergeBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression to the WITH clause
+// of the query.
+//
+// See SelectBuilder.WithRecursive for more information.
+func (b MergeBuilder) WithRecursive(name string, columns []string, query Subquery) MergeBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, columns: columns, query: query, recursive: true})
+	return b
+}
+
+// WithMaterialized adds a materialized common table expression to the WITH
+// clause of the query.
+//
+// See SelectBuilder.WithMaterialized for more information.
+func (b MergeBuilder) WithMaterialized(name string, query Subquery) MergeBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query, materialized: true})
+	return b
+}
+
+// Into sets the target table of the query.
+func (b MergeBuilder) Into(into string) MergeBuilder {
+	b.data.Into = into
+	return b
+}
+
+// Using sets the source table of the query.
+func (b MergeBuilder) Using(source string) MergeBuilder {
+	b.data.Using = newPart(source)
+	return b
+}
+
+// UsingSelect sets a subquery as the source of the query.
+func (b MergeBuilder) UsingSelect(source Subquery, alias string) MergeBuilder {
+	b.data.Using = Alias(source, alias)
+	return b
+}
+
+// On adds expressions to the ON condition that matches source rows with
+// target rows.
+//
+// See SelectBuilder.Where for the accepted predicates.
+func (b MergeBuilder) On(pred interface{}, args ...interface{}) MergeBuilder {
+	b.data.OnParts = appendCopy(b.data.OnParts, newWherePart(pred, args...))
+	return b
+}
+
+// WhenMatched starts a WHEN MATCHED branch, for target rows matched by a
+// source row. If conditions are given, the branch only applies to the rows
+// matching all of them.
+func (b MergeBuilder) WhenMatched(conditions ...Sqlizer) MergeBuilder {
+	return b.when(mergeMatched, conditions)
+}
+
+// WhenNotMatched starts a WHEN NOT MATCHED branch, for source rows without a
+// matching target row. If conditions are given, the branch only applies to the
+// rows matching all of them.
+func (b MergeBuilder) WhenNotMatched(conditions ...Sqlizer) MergeBuilder {
+	return b.when(mergeNotMatched, conditions)
+}
+
+// WhenNotMatchedBySource starts a WHEN NOT MATCHED BY SOURCE branch, for
+// target rows without a matching source row. It's supported by SQL Server and
+// Postgres 17 and later.
+func (b MergeBuilder) WhenNotMatchedBySource(conditions ...Sqlizer) MergeBuilder {
+	return b.when(mergeNotMatchedBySource, conditions)
+}
+
+func (b MergeBuilder) when(match mergeMatch, conditions []Sqlizer) MergeBuilder {
+	b.data.Whens = appendCopy(b.data.Whens, mergeWhen{match: match, conditions: appendCopy(nil, conditions...)})
+	return b
+}
+
+// ThenSet adds a SET clause to the UPDATE action of the current WHEN branch.
+//
+// ToSql returns an error if no WHEN branch has been started, or the branch
+// already has another action.
+func (b MergeBuilder) ThenSet(column string, value interface{}) MergeBuilder {
+	return b.then(mergeActionUpdate, func(w *mergeWhen) {
+		w.sets = appendCopy(w.sets, setClause{column: column, value: value})
+	})
+}
+
+// ThenSetMap is a convenience method which calls .ThenSet for each key/value
+// pair in clauses.
+func (b MergeBuilder) ThenSetMap(clauses map[string]interface{}) MergeBuilder {
+	keys := make([]string, 0, len(clauses))
+	for key := range clauses {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b = b.ThenSet(key, clauses[key])
+	}
+	return b
+}
+
+// ThenInsert sets the action of the current WHEN branch to insert a row with
+// the given columns and values. Values may be Sqlizers, such as Expr("s.id")
+// to insert a column of the source row. Without columns and values, DEFAULT
+// VALUES are inserted.
+//
+// ToSql returns an error if no WHEN branch has been started, or the branch
+// already has another action.
+func (b MergeBuilder) ThenInsert(columns []string, values ...interface{}) MergeBuilder {
+	return b.then(mergeActionInsert, func(w *mergeWhen) {
+		w.columns = appendCopy([]string(nil), columns...)
+		w.values = appendCopy(nil, values...)
+	})
+}
+
+// ThenDelete sets the action of the current WHEN branch to delete the target
+// row.
+//
+// ToSql returns an error if no WHEN branch has been started, or the branch
+// already has another action.
+func (b MergeBuilder) ThenDelete() MergeBuilder {
+	return b.then(mergeActionDelete, nil)
+}
+
+// ThenDoNothing sets the action of the current WHEN branch to skip the row.
+// It's only supported by Postgres.
+//
+// ToSql returns an error if no WHEN branch has been started, or the branch
+// already has another action.
+func (b MergeBuilder) ThenDoNothing() MergeBuilder {
+	return b.then(mergeActionDoNothing, nil)
+}
+
+func (b MergeBuilder) then(action mergeAction, fn func(*mergeWhen)) MergeBuilder {
+	if b.data.Err != nil {
+		return b
+	}
+	if len(b.data.Whens) == 0 {
+		b.data.Err = fmt.Errorf("merge %s actions must follow WhenMatched, WhenNotMatched or WhenNotMatchedBySource", action)
+		return b
+	}
+
+	// copy the branches so builders derived from b don't share the updated branch
+	whens := append([]mergeWhen(nil), b.data.Whens...)
+	when := &whens[len(whens)-1]
+	if when.action != mergeActionNone && when.action != action {
+		b.data.Err = fmt.Errorf("WHEN %s clause can't both %s and %s", when.match, when.action, action)
+		return b
+	}
+
+	when.action = action
+	if fn != nil {
+		fn(when)
+	}
+
+	b.data.Whens = whens
+	return b
+}
+
+// Suffix adds an expression to the end of the query
+func (b MergeBuilder) Suffix(sql string, args ...interface{}) MergeBuilder {
+	return b.SuffixExpr(Expr(sql, args...))
+}
+
+// SuffixExpr adds an expression to the end of the query
+func (b MergeBuilder) SuffixExpr(expr Sqlizer) MergeBuilder {
+	b.data.Suffixes = appendCopy(b.data.Suffixes, expr)
+	return b
+}
@@ -0,0 +1,176 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBuilderToSql(t *testing.T) {
+	b := Merge("accounts a").
+		Prefix("WITH prefix AS ?", 0).
+		UsingSelect(Select("id", "balance", "closed").From("staged_accounts").Where("batch = ?", 1), "s").
+		On("a.id = s.id").
+		On(Eq{"a.tenant": 2}).
+		WhenMatched(Expr("s.closed")).ThenDelete().
+		WhenMatched(Expr("a.balance <> s.balance")).
+		ThenSet("balance", Expr("s.balance")).
+		ThenSet("updated_by", "sync").
+		WhenNotMatched().ThenInsert([]string{"id", "tenant", "balance"}, Expr("s.id"), 2, Expr("s.balance")).
+		WhenNotMatched().ThenDoNothing().
+		Suffix("RETURNING ?", 3)
+
+	sql, args, err := b.ToSql()
+	assert.NoError(t, err)
+
+	expectedSql :=
+		"WITH prefix AS ? " +
+			"MERGE INTO accounts a " +
+			"USING (SELECT id, balance, closed FROM staged_accounts WHERE batch = ?) AS s " +
+			"ON a.id = s.id AND a.tenant = ? " +
+			"WHEN MATCHED AND s.closed THEN DELETE " +
+			"WHEN MATCHED AND a.balance <> s.balance THEN UPDATE SET balance = s.balance, updated_by = ? " +
+			"WHEN NOT MATCHED THEN INSERT (id,tenant,balance) VALUES (s.id,?,s.balance) " +
+			"WHEN NOT MATCHED THEN DO NOTHING " +
+			"RETURNING ?"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{0, 1, 2, "sync", 2, 3}, args)
+}
+
+func TestMergeBuilderPlaceholders(t *testing.T) {
+	b := Merge("t").
+		Using("s").
+		On("t.id = s.id").
+		WhenMatched(Expr("s.version > ?", 1)).ThenSetMap(map[string]interface{}{"b": 3, "a": Expr("s.a")}).
+		WhenNotMatchedBySource().ThenDelete()
+
+	sql, args, err := b.PlaceholderFormat(Dollar).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.version > $1 THEN UPDATE SET a = s.a, b = $2 WHEN NOT MATCHED BY SOURCE THEN DELETE", sql)
+	assert.Equal(t, []interface{}{1, 3}, args)
+
+	sql, _, err = StatementBuilder.Dialect(SqlServer).Merge("t").
+		Using("s").
+		On("t.id = s.id").
+		WhenNotMatched().ThenInsert([]string{"id", "a"}, Expr("s.id"), 1).
+		Suffix(";").
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN NOT MATCHED THEN INSERT (id,a) VALUES (s.id,@p1) ;", sql)
+}
+
+func TestMergeBuilderInsertDefaultValues(t *testing.T) {
+	sql, _, err := Merge("t").Using("s").On("t.id = s.id").WhenNotMatched().ThenInsert(nil).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN NOT MATCHED THEN INSERT DEFAULT VALUES", sql)
+}
+
+func TestMergeBuilderToSqlErr(t *testing.T) {
+	base := Merge("t").Using("s").On("t.id = s.id")
+
+	_, _, err := Merge("").Using("s").On("t.id = s.id").WhenMatched().ThenDelete().ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Merge("t").On("t.id = s.id").WhenMatched().ThenDelete().ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Merge("t").Using("s").WhenMatched().ThenDelete().ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.WhenMatched().ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.WhenNotMatched().ThenInsert([]string{"a", "b"}, 1).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.WhenNotMatched().ThenInsert([]string{"a", "b"}).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.ThenDelete().WhenMatched().ThenDelete().ToSql()
+	assert.EqualError(t, err, "merge DELETE actions must follow WhenMatched, WhenNotMatched or WhenNotMatchedBySource")
+
+	_, _, err = base.WhenMatched().ThenDelete().ThenSet("a", 1).ToSql()
+	assert.EqualError(t, err, "WHEN MATCHED clause can't both DELETE and UPDATE")
+
+	_, _, err = base.WhenMatched().ThenInsert([]string{"a"}, 1).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.WhenNotMatchedBySource().ThenInsert(nil).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.WhenNotMatched().ThenSet("a", 1).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = base.WhenNotMatched().ThenDelete().ToSql()
+	assert.Error(t, err)
+}
+
+func TestMergeBuilderDialect(t *testing.T) {
+	base := Merge("t").Using("s").On("t.id = s.id").WhenMatched().ThenDelete()
+
+	for _, d := range []Dialect{Sqlite, MySql, MySql8} {
+		_, _, err := base.Dialect(d).ToSql()
+		assert.Error(t, err, d.Name())
+	}
+
+	_, _, err := base.WhenNotMatched().ThenDoNothing().Dialect(SqlServer).ToSql()
+	assert.Error(t, err)
+
+	sql, _, err := base.WhenNotMatched().ThenDoNothing().Dialect(Postgres).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE WHEN NOT MATCHED THEN DO NOTHING", sql)
+}
+
+func TestMergeBuilderImmutable(t *testing.T) {
+	base := Merge("t").Using("s").On("t.id = s.id").WhenMatched().ThenSet("a", 1)
+
+	withB := base.ThenSet("b", 2)
+	withC := base.ThenSet("c", 3)
+
+	sql, _, err := withB.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET a = ?, b = ?", sql)
+
+	sql, _, err = withC.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET a = ?, c = ?", sql)
+}
+
+func TestMergeBuilderSubqueryValues(t *testing.T) {
+	totals := Union(Select("sum(a)").From("x"), Select("sum(a)").From("y").Where("b = ?", 1))
+
+	sql, args, err := Merge("t").Using("s").On("t.id = s.id").
+		WhenMatched().ThenSet("a", totals).
+		WhenNotMatched().ThenInsert([]string{"id", "a"}, Expr("s.id"), Select("max(a)").From("z")).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"MERGE INTO t USING s ON t.id = s.id "+
+			"WHEN MATCHED THEN UPDATE SET a = (SELECT sum(a) FROM x UNION SELECT sum(a) FROM y WHERE b = ?) "+
+			"WHEN NOT MATCHED THEN INSERT (id,a) VALUES (s.id,(SELECT max(a) FROM z))",
+		sql)
+	assert.Equal(t, []interface{}{1}, args)
+}
+
+func TestMergeBuilderCopiesArgs(t *testing.T) {
+	conditions := []Sqlizer{Expr("s.a > 1")}
+	values := []interface{}{Expr("s.id"), 1}
+
+	b := Merge("t").Using("s").On("t.id = s.id").
+		WhenMatched(conditions...).ThenDelete().
+		WhenNotMatched().ThenInsert([]string{"id", "a"}, values...)
+	conditions[0] = Expr("s.a > 2")
+	values[1] = 2
+
+	sql, args, err := b.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.a > 1 THEN DELETE WHEN NOT MATCHED THEN INSERT (id,a) VALUES (s.id,?)", sql)
+	assert.Equal(t, []interface{}{1}, args)
+}
+
+func TestMergeBuilderMustSql(t *testing.T) {
+	assert.Panics(t, func() { Merge("").MustSql() })
+}
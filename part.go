@@ -14,12 +14,16 @@ func newPart(pred interface{}, args ...interface{}) Sqlizer {
 	return &part{pred, args}
 }
 
-func (p part) ToSql() (sql string, args []interface{}, err error) {
+func (p part) ToSql() (string, []interface{}, error) {
+	return p.toSqlDialect(nil)
+}
+
+func (p part) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
 	switch pred := p.pred.(type) {
 	case nil:
 		// no-op
 	case Sqlizer:
-		sql, args, err = nestedToSql(pred)
+		sql, args, err = nestedToSql(pred, d)
 	case string:
 		sql = pred
 		args = p.args
@@ -29,17 +33,31 @@ func (p part) ToSql() (sql string, args []interface{}, err error) {
 	return
 }
 
-func nestedToSql(s Sqlizer) (string, []interface{}, error) {
-	if raw, ok := s.(rawSqlizer); ok {
-		return raw.toSqlRaw()
-	} else {
+// dialectSqlizer is implemented by expressions whose SQL depends on the Dialect
+// of the statement they're rendered in, such as tuple comparisons, and by the
+// expressions that nest other expressions, so the statement's Dialect reaches
+// them. ToSql renders them for the default dialect.
+type dialectSqlizer interface {
+	toSqlDialect(d Dialect) (string, []interface{}, error)
+}
+
+// nestedToSql renders s as part of a statement using dialect d. Subqueries are
+// rendered with their own dialect, or d if they don't have one, without
+// numbering their placeholders.
+func nestedToSql(s Sqlizer, d Dialect) (string, []interface{}, error) {
+	switch s := s.(type) {
+	case rawSqlizer:
+		return s.toSqlRaw(d)
+	case dialectSqlizer:
+		return s.toSqlDialect(d)
+	default:
 		return s.ToSql()
 	}
 }
 
-func appendToSql(parts []Sqlizer, w io.Writer, sep string, args []interface{}) ([]interface{}, error) {
+func appendToSql(parts []Sqlizer, d Dialect, w io.Writer, sep string, args []interface{}) ([]interface{}, error) {
 	for i, p := range parts {
-		partSql, partArgs, err := nestedToSql(p)
+		partSql, partArgs, err := nestedToSql(p, d)
 		if err != nil {
 			return nil, err
 		} else if len(partSql) == 0 {
@@ -61,3 +79,9 @@ func appendToSql(parts []Sqlizer, w io.Writer, sep string, args []interface{}) (
 	}
 	return args, nil
 }
+
+// appendCopy appends values to a copy of s, so builders that share s with the
+// builder they were derived from never see each other's values.
+func appendCopy[T any](s []T, values ...T) []T {
+	return append(s[:len(s):len(s)], values...)
+}
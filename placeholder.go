@@ -1,7 +1,6 @@
 package squirrelly
 
 import (
-	"bytes"
 	"fmt"
 	"strings"
 )
@@ -10,6 +9,11 @@ import (
 //
 // ReplacePlaceholders takes a SQL statement and replaces each question mark
 // placeholder with a (possibly different) SQL placeholder.
+//
+// The positional formats only look for placeholders in code, so a question
+// mark inside a string, quoted identifier or comment is left alone. Before,
+// it was numbered like any other placeholder. An escaped ?? is still written
+// as a single question mark wherever it appears.
 type PlaceholderFormat interface {
 	ReplacePlaceholders(sql string) (string, error)
 }
@@ -76,6 +80,20 @@ func (atpFormat) debugPlaceholder() string {
 	return "@p"
 }
 
+// replacePlaceholders replaces the placeholders in sql using f, leaving them
+// as question marks if no format is set. Positional placeholders skip the
+// strings, quoted identifiers and comments of dialect d's SqlSyntax.
+func replacePlaceholders(f PlaceholderFormat, d Dialect, sql string) (string, error) {
+	if f == nil {
+		return sql, nil
+	}
+
+	if pd, ok := f.(placeholderDebugger); ok && pd.debugPlaceholder() != "?" {
+		return replaceSyntaxPlaceholders(sql, pd.debugPlaceholder(), dialectOrDefault(d).Syntax()), nil
+	}
+	return f.ReplacePlaceholders(sql)
+}
+
 // Placeholders returns a string with count ? placeholders joined with commas.
 func Placeholders(count int) string {
 	if count < 1 {
@@ -85,30 +103,29 @@ func Placeholders(count int) string {
 	return strings.Repeat(",?", count)[1:]
 }
 
+// replacePositionalPlaceholders replaces the placeholders in sql with prefix
+// followed by their position, skipping ANSI strings, quoted identifiers and
+// comments.
 func replacePositionalPlaceholders(sql, prefix string) (string, error) {
-	buf := &bytes.Buffer{}
+	return replaceSyntaxPlaceholders(sql, prefix, SqlSyntax{}), nil
+}
+
+// replaceSyntaxPlaceholders replaces the placeholders in sql with prefix
+// followed by their position, skipping the strings, quoted identifiers and
+// comments of syntax.
+func replaceSyntaxPlaceholders(sql, prefix string, syntax SqlSyntax) string {
+	buf := &strings.Builder{}
 	i := 0
-	for {
-		p := strings.Index(sql, "?")
-		if p == -1 {
-			break
+	for _, token := range syntax.tokenize(sql) {
+		if !token.code {
+			buf.WriteString(unescapeLiteral(token.text))
+			continue
 		}
 
-		if len(sql[p:]) > 1 && sql[p:p+2] == "??" { // escape ?? => ?
-			buf.WriteString(sql[:p])
-			buf.WriteString("?")
-			if len(sql[p:]) == 1 {
-				break
-			}
-			sql = sql[p+2:]
-		} else {
+		syntax.scanPlaceholders(token.text, buf, func() {
 			i++
-			buf.WriteString(sql[:p])
 			fmt.Fprintf(buf, "%s%d", prefix, i)
-			sql = sql[p+1:]
-		}
+		})
 	}
-
-	buf.WriteString(sql)
-	return buf.String(), nil
+	return buf.String()
 }
@@ -1,6 +1,7 @@
 package squirrelly
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -35,22 +36,130 @@ func TestPlaceholders(t *testing.T) {
 	assert.Equal(t, Placeholders(2), "?,?")
 }
 
+// A lone ? inside a string is left alone, rather than numbered as it was before
+// placeholders were only looked for in code.
 func TestEscapeDollar(t *testing.T) {
 	sql := "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ??| array['?'] AND enabled = ?"
 	s, _ := Dollar.ReplacePlaceholders(sql)
-	assert.Equal(t, "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ?| array['$1'] AND enabled = $2", s)
+	assert.Equal(t, "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ?| array['?'] AND enabled = $1", s)
 }
 
 func TestEscapeColon(t *testing.T) {
 	sql := "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ??| array['?'] AND enabled = ?"
 	s, _ := Colon.ReplacePlaceholders(sql)
-	assert.Equal(t, "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ?| array[':1'] AND enabled = :2", s)
+	assert.Equal(t, "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ?| array['?'] AND enabled = :1", s)
 }
 
 func TestEscapeAtp(t *testing.T) {
 	sql := "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ??| array['?'] AND enabled = ?"
 	s, _ := AtP.ReplacePlaceholders(sql)
-	assert.Equal(t, "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ?| array['@p1'] AND enabled = @p2", s)
+	assert.Equal(t, "SELECT uuid, \"data\" #> '{tags}' AS tags FROM nodes WHERE  \"data\" -> 'tags' ?| array['?'] AND enabled = @p1", s)
+}
+
+func TestEscapeInLiterals(t *testing.T) {
+	sql := "SELECT '??', \"a??\" /* ?? */ FROM t WHERE a = ? AND b = '???' -- ??"
+	s, _ := Dollar.ReplacePlaceholders(sql)
+	assert.Equal(t, "SELECT '?', \"a?\" /* ? */ FROM t WHERE a = $1 AND b = '??' -- ?", s)
+}
+
+func TestDollarSkipsLiterals(t *testing.T) {
+	sql := `SELECT '?', "?", 'it''s ?' /* ? */ FROM t -- ?
+WHERE a = ? AND b = ?`
+	s, _ := Dollar.ReplacePlaceholders(sql)
+	assert.Equal(t, `SELECT '?', "?", 'it''s ?' /* ? */ FROM t -- ?
+WHERE a = $1 AND b = $2`, s)
+}
+
+func TestReplacePlaceholdersSyntax(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		sql      string
+		expected string
+	}{
+		{Postgres, "a ?| ? AND b ?& ? AND c = ? || ?", "a ?| $1 AND b ?& $2 AND c = $3 || $4"},
+		{Postgres, "a = $$?$$ AND b = $x$ '?' $x$ AND c = ?", "a = $$?$$ AND b = $x$ '?' $x$ AND c = $1"},
+		{Postgres, "a$b = ? AND c = `?`", "a$b = $1 AND c = `$2`"},
+		{MySql, "a = 'it\\'s ?' AND `?` = ? # ?", "a = 'it\\'s ?' AND `?` = $1 # ?"},
+		{SqlServer, "[?] = ? AND a = '\\' AND b = ?", "[?] = $1 AND a = '\\' AND b = $2"},
+		{Sqlite, "[?] = ? AND `?` = ?", "[?] = $1 AND `?` = $2"},
+	}
+
+	for _, test := range tests {
+		s, err := replacePlaceholders(Dollar, test.dialect, test.sql)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, s, test.dialect.Name())
+	}
+}
+
+// legacyReplacePositionalPlaceholders is replacePositionalPlaceholders as it
+// was before it skipped literals and comments.
+func legacyReplacePositionalPlaceholders(sql, prefix string) string {
+	buf := &strings.Builder{}
+	i := 0
+	for {
+		p := strings.Index(sql, "?")
+		if p == -1 {
+			break
+		}
+
+		if len(sql[p:]) > 1 && sql[p:p+2] == "??" { // escape ?? => ?
+			buf.WriteString(sql[:p])
+			buf.WriteString("?")
+			sql = sql[p+2:]
+		} else {
+			i++
+			buf.WriteString(sql[:p])
+			fmt.Fprintf(buf, "%s%d", prefix, i)
+			sql = sql[p+1:]
+		}
+	}
+
+	buf.WriteString(sql)
+	return buf.String()
+}
+
+func FuzzReplacePositionalPlaceholders(f *testing.F) {
+	f.Add("x = ? AND y = ?")
+	f.Add("x ?? y AND z IN (?, ?, ?)")
+	f.Add("???")
+	f.Add("?")
+
+	f.Fuzz(func(t *testing.T, sql string) {
+		// Plain SQL, without any strings, quoted identifiers or comments
+		if strings.ContainsAny(sql, "'\"`[#$\\") || strings.Contains(sql, "--") || strings.Contains(sql, "/*") {
+			t.Skip()
+		}
+
+		for _, prefix := range []string{"$", ":", "@p"} {
+			s, err := replacePositionalPlaceholders(sql, prefix)
+			assert.NoError(t, err)
+			assert.Equal(t, legacyReplacePositionalPlaceholders(sql, prefix), s)
+		}
+	})
+}
+
+func FuzzSqlSyntaxTokenize(f *testing.F) {
+	f.Add("SELECT 'a''b', \"c\" FROM t -- ?\nWHERE x = ?")
+	f.Add("a = 'it\\'s' AND `b` = [c] # d")
+	f.Add("a = $$?$$ AND b = $x$ '?' $x$ AND c = $1 /* e */")
+	f.Add("a = 'unterminated")
+	f.Add("a ?| b ?& c ?? d")
+
+	syntaxes := []SqlSyntax{{}}
+	for _, d := range []Dialect{Sqlite, Postgres, MySql, MySql8, SqlServer} {
+		syntaxes = append(syntaxes, d.Syntax())
+	}
+
+	f.Fuzz(func(t *testing.T, sql string) {
+		for _, syntax := range syntaxes {
+			text := &strings.Builder{}
+			for _, token := range syntax.tokenize(sql) {
+				assert.NotEmpty(t, token.text)
+				text.WriteString(token.text)
+			}
+			assert.Equal(t, sql, text.String(), "%+v", syntax)
+		}
+	})
 }
 
 func BenchmarkPlaceholdersArray(b *testing.B) {
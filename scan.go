@@ -0,0 +1,167 @@
+package squirrelly
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// scanPlan is a compiled mapping from a query's result columns to the fields of a struct type.
+// Plans are cached by their Mapper, so the columns of each (type, column set) pair are only resolved once.
+type scanPlan struct {
+	typ     reflect.Type
+	columns []string
+
+	// fields holds the field index of each column, or nil if the column isn't mapped
+	fields [][]int
+
+	// flat is set when every column maps to a top level field, so no pointers need to be allocated while scanning
+	flat bool
+}
+
+// maxScanPlans bounds the scan plans cached by a Mapper. Queries usually come
+// from a fixed set of column lists, but ones with dynamically built column
+// lists would otherwise grow the cache without end, so a full cache is
+// replaced by an empty one, to be refilled by the plans still in use.
+const maxScanPlans = 1024
+
+// scanPlanCache holds the scan plans of a Mapper. A slot is counted in count
+// before each plan is stored, so it never holds more than maxScanPlans.
+type scanPlanCache struct {
+	plans sync.Map
+	count atomic.Int64
+}
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	columns string
+}
+
+// scanPlan returns the cached scan plan for scanning columns into t, compiling it if needed.
+func (m *Mapper) scanPlan(t reflect.Type, columns []string) *scanPlan {
+	t = reflectx.Deref(t)
+	key := scanPlanKey{typ: t, columns: strings.Join(columns, "\x00")}
+	cache := m.plans.Load()
+	if plan, ok := cache.plans.Load(key); ok {
+		return plan.(*scanPlan)
+	}
+
+	typeMap := m.typeMap(t)
+	plan := &scanPlan{
+		typ:     t,
+		columns: append([]string(nil), columns...),
+		fields:  make([][]int, len(columns)),
+		flat:    true,
+	}
+	for idx, column := range columns {
+		if field, ok := m.field(typeMap, column); ok {
+			plan.fields[idx] = field.Index
+			plan.flat = plan.flat && len(field.Index) == 1
+		}
+	}
+
+	if cache.count.Add(1) > maxScanPlans {
+		fresh := &scanPlanCache{}
+		fresh.count.Store(1)
+		if !m.plans.CompareAndSwap(cache, fresh) {
+			// another scan replaced the full cache first, leave this plan out
+			return plan
+		}
+		cache = fresh
+	}
+
+	actual, _ := cache.plans.LoadOrStore(key, plan)
+	return actual.(*scanPlan)
+}
+
+// validate returns an error if any of the plan's columns can't be mapped.
+func (p *scanPlan) validate() error {
+	for idx, field := range p.fields {
+		if field == nil {
+			return fmt.Errorf("missing destination name %s in %s", p.columns[idx], p.typ.Name())
+		}
+	}
+	return nil
+}
+
+// targets fills dest with pointers to the fields of elem, which must be an addressable struct value, for use with [database/sql.Rows.Scan].
+func (p *scanPlan) targets(elem reflect.Value, dest []interface{}) {
+	if p.flat {
+		for idx, field := range p.fields {
+			dest[idx] = elem.Field(field[0]).Addr().Interface()
+		}
+		return
+	}
+
+	for idx, field := range p.fields {
+		dest[idx] = reflectx.FieldByIndexes(elem, field).Addr().Interface()
+	}
+}
+
+// isScannable reports whether values of typ are scanned directly, rather than mapped through struct fields.
+func isScannable(typ reflect.Type) bool {
+	return typ.Kind() != reflect.Struct || isAtomicType(typ)
+}
+
+func scanRows(rows *sql.Rows, fn func(*sql.Rows) error) error {
+	defer rows.Close()
+	for rows.Next() {
+		err := fn(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func structScan(mapper *Mapper, rows *sql.Rows, destination interface{}) error {
+	dest := reflect.ValueOf(destination)
+	if dest.Kind() != reflect.Ptr {
+		return errors.New("destination is not a pointer")
+	}
+	typ := dest.Elem().Type()
+
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	if isScannable(typ) {
+		if err := rows.Scan(destination); err != nil {
+			return errors.New("destination is not a struct, and could not be scanned")
+		}
+		return nil
+	}
+
+	// we don't need to error check -- rows can't be closed yet
+	columns, _ := rows.Columns()
+
+	plan := mapper.scanPlan(typ, columns)
+	if err := plan.validate(); err != nil {
+		return err
+	}
+
+	scanInterfaces := make([]interface{}, len(columns))
+	plan.targets(dest.Elem(), scanInterfaces)
+
+	err := rows.Scan(scanInterfaces...)
+	if err != nil {
+		return err
+	}
+
+	if rows.Next() {
+		return errors.New("trying to scan multiple rows into a single struct")
+	}
+
+	return rows.Err()
+}
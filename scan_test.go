@@ -0,0 +1,266 @@
+package squirrelly
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+type scanPlanAuthor struct {
+	Id   int    `sq:"id"`
+	Name string `sq:"name"`
+}
+
+type scanPlanRecord struct {
+	Pk      int             `sq:"pk"`
+	Comment string          `sq:"comment"`
+	Author  *scanPlanAuthor `sq:"author"`
+}
+
+func TestScanPlan(t *testing.T) {
+	mapper := NewMapper()
+	typ := reflect.TypeOf(scanPlanRecord{})
+
+	plan := mapper.scanPlan(typ, []string{"pk", "comment"})
+	assert.Equal(t, [][]int{{0}, {1}}, plan.fields)
+	assert.True(t, plan.flat)
+	assert.NoError(t, plan.validate())
+
+	// plans are cached by type and column set
+	assert.Same(t, plan, mapper.scanPlan(reflect.PointerTo(typ), []string{"pk", "comment"}))
+	assert.NotSame(t, plan, mapper.scanPlan(typ, []string{"comment", "pk"}))
+	assert.NotSame(t, plan, mapper.scanPlan(typ, []string{"pk,comment"}))
+
+	nested := mapper.scanPlan(typ, []string{"pk", "author.name"})
+	assert.Equal(t, [][]int{{0}, {2, 1}}, nested.fields)
+	assert.False(t, nested.flat)
+
+	record := scanPlanRecord{}
+	targets := make([]interface{}, 2)
+	nested.targets(reflect.ValueOf(&record).Elem(), targets)
+	assert.Equal(t, &record.Pk, targets[0])
+	assert.NotNil(t, record.Author)
+	assert.Equal(t, &record.Author.Name, targets[1])
+
+	missing := mapper.scanPlan(typ, []string{"pk", "missing"})
+	assert.EqualError(t, missing.validate(), "missing destination name missing in scanPlanRecord")
+}
+
+func TestScanPlanCacheBounded(t *testing.T) {
+	mapper := NewMapper()
+	typ := reflect.TypeOf(scanPlanRecord{})
+
+	for i := 0; i < maxScanPlans*2+10; i++ {
+		mapper.scanPlan(typ, []string{"pk", fmt.Sprintf("column_%d", i)})
+	}
+
+	cache := mapper.plans.Load()
+	cached := 0
+	cache.plans.Range(func(_, _ any) bool {
+		cached++
+		return true
+	})
+	assert.LessOrEqual(t, cached, maxScanPlans)
+	assert.Equal(t, int64(cached), cache.count.Load())
+
+	plan := mapper.scanPlan(typ, []string{"pk", "comment"})
+	assert.Same(t, plan, mapper.scanPlan(typ, []string{"pk", "comment"}))
+}
+
+func openScanBenchmarkDb(b *testing.B, rows int) *Db {
+	db, err := Open("sqlite", "file::memory:")
+	if err != nil {
+		b.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	_, err = db.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, comment TEXT NOT NULL, score INTEGER NOT NULL, created_at TEXT NOT NULL)")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	insert := Insert("foo").Columns("pk", "comment", "score", "created_at")
+	for i := 0; i < rows; i++ {
+		insert = insert.Values(i, fmt.Sprintf("comment %d", i), i*10, "2024-01-01")
+	}
+	if _, err := db.Exec(insert); err != nil {
+		b.Fatal(err)
+	}
+
+	return db
+}
+
+type scanBenchmarkRecord struct {
+	Pk        int    `sq:"pk"`
+	Comment   string `sq:"comment"`
+	Score     int    `sq:"score"`
+	CreatedAt string `sq:"created_at"`
+}
+
+var legacyMapper = reflectx.NewMapper("sq")
+
+// legacyGet is the single row scanning path used before scan plans were introduced, kept for comparison in benchmarks.
+func legacyGet(db DbLike, query Sqlizer, destination any) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return sql.ErrNoRows
+	}
+
+	dest := reflect.ValueOf(destination)
+	typ := dest.Elem().Type()
+	columns, _ := rows.Columns()
+
+	fieldTraversals := legacyMapper.TraversalsByName(typ, columns)
+	scanInterfaces := make([]interface{}, len(columns))
+	for idx, f := range fieldTraversals {
+		if len(f) == 0 {
+			return fmt.Errorf("missing destination name %s in %s", columns[idx], typ.Name())
+		}
+		scanInterfaces[idx] = reflectx.FieldByIndexes(dest, f).Addr().Interface()
+	}
+
+	if err := rows.Scan(scanInterfaces...); err != nil {
+		return err
+	}
+
+	if rows.Next() {
+		return errors.New("trying to scan multiple rows into a single struct")
+	}
+	return nil
+}
+
+// legacyGetAll is the scanning path used before scan plans were introduced, kept for comparison in benchmarks.
+func legacyGetAll(db DbLike, query Sqlizer, container any) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+
+	containerValue := reflect.ValueOf(container).Elem()
+	containerType := containerValue.Type()
+	elemType := containerType.Elem()
+
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	fieldTraversals := legacyMapper.TraversalsByName(elemType, columns)
+	for idx, f := range fieldTraversals {
+		if len(f) == 0 {
+			return fmt.Errorf("missing destination name %s in %s", columns[idx], elemType.Name())
+		}
+	}
+
+	rawValues := []reflect.Value{}
+	err = scanRows(rows, func(row *sql.Rows) error {
+		elem := reflect.New(elemType)
+
+		elemValues := make([]interface{}, len(columns))
+		for idx, traversal := range fieldTraversals {
+			field := reflectx.FieldByIndexes(elem, traversal)
+			elemValues[idx] = field.Addr().Interface()
+		}
+
+		err := row.Scan(elemValues...)
+		if err != nil {
+			return err
+		}
+
+		if !isPtr {
+			elem = reflect.Indirect(elem)
+		}
+
+		rawValues = append(rawValues, elem)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	containerValue.Set(reflect.MakeSlice(containerType, len(rawValues), len(rawValues)))
+	for idx, value := range rawValues {
+		containerValue.Index(idx).Set(value)
+	}
+
+	return nil
+}
+
+func BenchmarkGetAll(b *testing.B) {
+	getAlls := map[string]func(DbLike, Sqlizer, any) error{
+		"legacy": legacyGetAll,
+		"plan":   DbGetAll,
+	}
+
+	for _, rows := range []int{1, 10, 1000} {
+		db := openScanBenchmarkDb(b, rows)
+		query := Select("pk", "comment", "score", "created_at").From("foo")
+
+		for _, name := range []string{"legacy", "plan"} {
+			getAll := getAlls[name]
+
+			b.Run(fmt.Sprintf("rows=%d/%s/values", rows, name), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					records := []scanBenchmarkRecord{}
+					if err := getAll(db, query, &records); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			b.Run(fmt.Sprintf("rows=%d/%s/pointers", rows, name), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					records := []*scanBenchmarkRecord{}
+					if err := getAll(db, query, &records); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+
+		db.Close()
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	db := openScanBenchmarkDb(b, 1)
+	defer db.Close()
+
+	query := Select("pk", "comment", "score", "created_at").From("foo")
+
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			record := scanBenchmarkRecord{}
+			if err := legacyGet(db, query, &record); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("plan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			record := scanBenchmarkRecord{}
+			if err := db.Get(query, &record); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
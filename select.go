@@ -2,15 +2,20 @@ package squirrelly
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
-	"github.com/lann/builder"
+	"github.com/jmoiron/sqlx/reflectx"
 )
 
 type selectData struct {
 	PlaceholderFormat PlaceholderFormat
+	Dialect           Dialect
+	Mapper            *Mapper
 	Prefixes          []Sqlizer
+	With              []commonTableExpr
 	Options           []string
 	Columns           []Sqlizer
 	From              Sqlizer
@@ -18,9 +23,13 @@ type selectData struct {
 	WhereParts        []Sqlizer
 	GroupBys          []string
 	HavingParts       []Sqlizer
+	Windows           []Sqlizer
 	OrderByParts      []Sqlizer
 	Limit             string
 	Offset            string
+	LockStrength      string
+	LockOf            []string
+	LockWait          string
 	Suffixes          []Sqlizer
 }
 
@@ -30,7 +39,7 @@ func (d *selectData) ToSql() (sqlStr string, args []interface{}, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, d.Dialect, sqlStr)
 	return
 }
 
@@ -43,7 +52,7 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
-		args, err = appendToSql(d.Prefixes, sql, " ", args)
+		args, err = appendToSql(d.Prefixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
@@ -51,6 +60,11 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(" ")
 	}
 
+	args, err = appendWithToSql(d.With, d.Dialect, sql, args)
+	if err != nil {
+		return
+	}
+
 	sql.WriteString("SELECT ")
 
 	if len(d.Options) > 0 {
@@ -59,7 +73,7 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 	}
 
 	if len(d.Columns) > 0 {
-		args, err = appendToSql(d.Columns, sql, ", ", args)
+		args, err = appendToSql(d.Columns, d.Dialect, sql, ", ", args)
 		if err != nil {
 			return
 		}
@@ -67,7 +81,7 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 
 	if d.From != nil {
 		sql.WriteString(" FROM ")
-		args, err = appendToSql([]Sqlizer{d.From}, sql, "", args)
+		args, err = appendToSql([]Sqlizer{d.From}, d.Dialect, sql, "", args)
 		if err != nil {
 			return
 		}
@@ -75,7 +89,7 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 
 	if len(d.Joins) > 0 {
 		sql.WriteString(" ")
-		args, err = appendToSql(d.Joins, sql, " ", args)
+		args, err = appendToSql(d.Joins, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
@@ -83,7 +97,7 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 
 	if len(d.WhereParts) > 0 {
 		sql.WriteString(" WHERE ")
-		args, err = appendToSql(d.WhereParts, sql, " AND ", args)
+		args, err = appendToSql(d.WhereParts, d.Dialect, sql, " AND ", args)
 		if err != nil {
 			return
 		}
@@ -96,7 +110,15 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 
 	if len(d.HavingParts) > 0 {
 		sql.WriteString(" HAVING ")
-		args, err = appendToSql(d.HavingParts, sql, " AND ", args)
+		args, err = appendToSql(d.HavingParts, d.Dialect, sql, " AND ", args)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(d.Windows) > 0 {
+		sql.WriteString(" WINDOW ")
+		args, err = appendToSql(d.Windows, d.Dialect, sql, ", ", args)
 		if err != nil {
 			return
 		}
@@ -104,26 +126,37 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 
 	if len(d.OrderByParts) > 0 {
 		sql.WriteString(" ORDER BY ")
-		args, err = appendToSql(d.OrderByParts, sql, ", ", args)
+		args, err = appendToSql(d.OrderByParts, d.Dialect, sql, ", ", args)
 		if err != nil {
 			return
 		}
 	}
 
-	if len(d.Limit) > 0 {
-		sql.WriteString(" LIMIT ")
-		sql.WriteString(d.Limit)
+	if limitOffset := dialectOrDefault(d.Dialect).LimitOffset(d.Limit, d.Offset, len(d.OrderByParts) > 0); limitOffset != "" {
+		sql.WriteString(" ")
+		sql.WriteString(limitOffset)
 	}
 
-	if len(d.Offset) > 0 {
-		sql.WriteString(" OFFSET ")
-		sql.WriteString(d.Offset)
+	if d.LockStrength != "" {
+		var lock string
+		lock, err = dialectOrDefault(d.Dialect).RowLock(RowLock{Strength: d.LockStrength, Of: d.LockOf, Wait: d.LockWait})
+		if err != nil {
+			return
+		}
+
+		if lock != "" {
+			sql.WriteString(" ")
+			sql.WriteString(lock)
+		}
+	} else if len(d.LockOf) > 0 || d.LockWait != "" {
+		err = errors.New("select statements with Of, SkipLocked or NoWait must lock rows with ForUpdate, ForNoKeyUpdate or ForShare")
+		return
 	}
 
 	if len(d.Suffixes) > 0 {
 		sql.WriteString(" ")
 
-		args, err = appendToSql(d.Suffixes, sql, " ", args)
+		args, err = appendToSql(d.Suffixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
@@ -136,10 +169,8 @@ func (d *selectData) toSqlRaw() (sqlStr string, args []interface{}, err error) {
 // Builder
 
 // SelectBuilder builds SQL SELECT statements.
-type SelectBuilder builder.Builder
-
-func init() {
-	builder.Register(SelectBuilder{}, selectData{})
+type SelectBuilder struct {
+	data selectData
 }
 
 // Format methods
@@ -147,20 +178,41 @@ func init() {
 // PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
 // query.
 func (b SelectBuilder) PlaceholderFormat(f PlaceholderFormat) SelectBuilder {
-	return builder.Set(b, "PlaceholderFormat", f).(SelectBuilder)
+	b.data.PlaceholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect used to render the query, along with its
+// PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b SelectBuilder) Dialect(d Dialect) SelectBuilder {
+	b.data.Dialect = d
+	b.data.PlaceholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// Mapper sets the Mapper used to map struct fields to columns, instead of the
+// package level default.
+func (b SelectBuilder) Mapper(m *Mapper) SelectBuilder {
+	b.data.Mapper = m
+	return b
 }
 
 // SQL methods
 
 // ToSql builds the query into a SQL string and bound args.
 func (b SelectBuilder) ToSql() (string, []interface{}, error) {
-	data := builder.GetStruct(b).(selectData)
-	return data.ToSql()
+	return b.data.ToSql()
 }
 
-func (b SelectBuilder) toSqlRaw() (string, []interface{}, error) {
-	data := builder.GetStruct(b).(selectData)
-	return data.toSqlRaw()
+func (b SelectBuilder) toSqlRaw(d Dialect) (string, []interface{}, error) {
+	if b.data.Dialect == nil {
+		b.data.Dialect = d
+	}
+	return b.data.toSqlRaw()
+}
+
+func (b SelectBuilder) debugFormat() (PlaceholderFormat, Dialect) {
+	return b.data.PlaceholderFormat, b.data.Dialect
 }
 
 // MustSql builds the query into a SQL string and bound args.
@@ -180,7 +232,56 @@ func (b SelectBuilder) Prefix(sql string, args ...interface{}) SelectBuilder {
 
 // PrefixExpr adds an expression to the very beginning of the query
 func (b SelectBuilder) PrefixExpr(expr Sqlizer) SelectBuilder {
-	return builder.Append(b, "Prefixes", expr).(SelectBuilder)
+	b.data.Prefixes = appendCopy(b.data.Prefixes, expr)
+	return b
+}
+
+// With adds a common table expression to the WITH clause of the query, which
+// is rendered after any prefixes. The query's placeholders are numbered along
+// with the rest of the statement.
+func (b SelectBuilder) With(name string, query Subquery) SelectBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression with the given
+// columns to the WITH clause of the query, making it a WITH RECURSIVE clause.
+// The query usually combines a base case and a recursive step that selects
+// from name.
+func (b SelectBuilder) WithRecursive(name string, columns []string, query Subquery) SelectBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, columns: columns, query: query, recursive: true})
+	return b
+}
+
+// WithMaterialized adds a common table expression to the WITH clause of the
+// query, rendered as name AS MATERIALIZED (...) to force Postgres to compute
+// it once rather than inlining it.
+func (b SelectBuilder) WithMaterialized(name string, query Subquery) SelectBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query, materialized: true})
+	return b
+}
+
+// Union returns a CompoundBuilder combining the query with query using UNION.
+func (b SelectBuilder) Union(query Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.data.PlaceholderFormat, b.data.Dialect, "UNION", false, []Subquery{b, query})
+}
+
+// UnionAll returns a CompoundBuilder combining the query with query using
+// UNION ALL.
+func (b SelectBuilder) UnionAll(query Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.data.PlaceholderFormat, b.data.Dialect, "UNION", true, []Subquery{b, query})
+}
+
+// Intersect returns a CompoundBuilder combining the query with query using
+// INTERSECT.
+func (b SelectBuilder) Intersect(query Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.data.PlaceholderFormat, b.data.Dialect, "INTERSECT", false, []Subquery{b, query})
+}
+
+// Except returns a CompoundBuilder combining the query with query using
+// EXCEPT.
+func (b SelectBuilder) Except(query Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.data.PlaceholderFormat, b.data.Dialect, "EXCEPT", false, []Subquery{b, query})
 }
 
 // Distinct adds a DISTINCT clause to the query.
@@ -190,23 +291,55 @@ func (b SelectBuilder) Distinct() SelectBuilder {
 
 // Options adds select option to the query
 func (b SelectBuilder) Options(options ...string) SelectBuilder {
-	return builder.Extend(b, "Options", options).(SelectBuilder)
+	b.data.Options = appendCopy(b.data.Options, options...)
+	return b
 }
 
 // Columns adds result columns to the query.
 func (b SelectBuilder) Columns(columns ...string) SelectBuilder {
-	parts := make([]interface{}, 0, len(columns))
+	parts := make([]Sqlizer, 0, len(columns))
 	for _, str := range columns {
 		parts = append(parts, newPart(str))
 	}
-	return builder.Extend(b, "Columns", parts).(SelectBuilder)
+	b.data.Columns = appendCopy(b.data.Columns, parts...)
+	return b
+}
+
+// ColumnsFor adds a result column to the query for each column mapped by the
+// `sq` tags of data's struct type, in the order the fields are declared.
+//
+// If alias is set, each column is qualified with it and given a matching
+// result alias, for example `alias.column AS "alias.column"`, quoted by the
+// builder's Dialect. The scanners map these columns into a nested struct field
+// tagged with the alias, which allows columns from joined tables to share names
+// without colliding:
+//
+//	type PostWithAuthor struct {
+//		Post   Post `sq:"p"`
+//		Author User `sq:"u"`
+//	}
+//
+//	Select().
+//		ColumnsFor(Post{}, "p").
+//		ColumnsFor(User{}, "u").
+//		From("posts p").
+//		Join("users u ON u.id = p.author_id")
+//
+// Nested struct fields produce dotted columns in the same form, so
+// ColumnsFor(PostWithAuthor{}, "") generates the same column list as above.
+//
+// ColumnsFor panics if data isn't a struct.
+func (b SelectBuilder) ColumnsFor(data interface{}, alias string) SelectBuilder {
+	b.data.Columns = appendCopy(b.data.Columns, structColumns(mapperOrDefault(b.data.Mapper), data, alias)...)
+	return b
 }
 
 // RemoveColumns remove all columns from query.
 // Must add a new column with Column or Columns methods, otherwise
 // return a error.
 func (b SelectBuilder) RemoveColumns() SelectBuilder {
-	return builder.Delete(b, "Columns").(SelectBuilder)
+	b.data.Columns = nil
+	return b
 }
 
 // Column adds a result column to the query.
@@ -215,24 +348,26 @@ func (b SelectBuilder) RemoveColumns() SelectBuilder {
 //
 //	Column("IF(col IN ("+squirrel.Placeholders(3)+"), 1, 0) as col", 1, 2, 3)
 func (b SelectBuilder) Column(column interface{}, args ...interface{}) SelectBuilder {
-	return builder.Append(b, "Columns", newPart(column, args...)).(SelectBuilder)
+	b.data.Columns = appendCopy(b.data.Columns, newPart(column, args...))
+	return b
 }
 
 // From sets the FROM clause of the query.
 func (b SelectBuilder) From(from string) SelectBuilder {
-	return builder.Set(b, "From", newPart(from)).(SelectBuilder)
+	b.data.From = newPart(from)
+	return b
 }
 
 // FromSelect sets a subquery into the FROM clause of the query.
-func (b SelectBuilder) FromSelect(from SelectBuilder, alias string) SelectBuilder {
-	// Prevent misnumbered parameters in nested selects (#183).
-	from = from.PlaceholderFormat(Question)
-	return builder.Set(b, "From", Alias(from, alias)).(SelectBuilder)
+func (b SelectBuilder) FromSelect(from Subquery, alias string) SelectBuilder {
+	b.data.From = Alias(from, alias)
+	return b
 }
 
 // JoinClause adds a join clause to the query.
 func (b SelectBuilder) JoinClause(pred interface{}, args ...interface{}) SelectBuilder {
-	return builder.Append(b, "Joins", newPart(pred, args...)).(SelectBuilder)
+	b.data.Joins = appendCopy(b.data.Joins, newPart(pred, args...))
+	return b
 }
 
 // Join adds a JOIN clause to the query.
@@ -260,6 +395,59 @@ func (b SelectBuilder) CrossJoin(join string, rest ...interface{}) SelectBuilder
 	return b.JoinClause("CROSS JOIN "+join, rest...)
 }
 
+// FullJoin adds a FULL JOIN clause to the query.
+func (b SelectBuilder) FullJoin(join string, rest ...interface{}) SelectBuilder {
+	return b.JoinClause("FULL JOIN "+join, rest...)
+}
+
+// JoinOn adds a JOIN clause to the query, joining table on the condition on,
+// for example:
+//
+//	JoinOn("users u", And{Expr("u.id = p.author_id"), Eq{"u.active": true}})
+func (b SelectBuilder) JoinOn(table string, on Sqlizer) SelectBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: newPart(table), on: on})
+}
+
+// LeftJoinOn adds a LEFT JOIN clause to the query, joining table on the
+// condition on.
+func (b SelectBuilder) LeftJoinOn(table string, on Sqlizer) SelectBuilder {
+	return b.JoinClause(joinExpr{kind: "LEFT JOIN", target: newPart(table), on: on})
+}
+
+// JoinUsing adds a JOIN clause to the query, joining table on the columns it
+// shares with the query's other tables: JOIN table USING (columns).
+func (b SelectBuilder) JoinUsing(table string, columns ...string) SelectBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: newPart(table), using: columns})
+}
+
+// JoinSelect adds a JOIN clause to the query, joining the subquery sub, named
+// alias, on the condition on. Placeholders of sub are numbered along with the
+// rest of the query.
+func (b SelectBuilder) JoinSelect(sub Subquery, alias string, on Sqlizer) SelectBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: Alias(sub, alias), on: on})
+}
+
+// LeftJoinSelect adds a LEFT JOIN clause to the query, joining the subquery
+// sub, named alias, on the condition on.
+//
+// See SelectBuilder.JoinSelect for more information.
+func (b SelectBuilder) LeftJoinSelect(sub Subquery, alias string, on Sqlizer) SelectBuilder {
+	return b.JoinClause(joinExpr{kind: "LEFT JOIN", target: Alias(sub, alias), on: on})
+}
+
+// LateralJoin adds a JOIN LATERAL clause to the query, joining the subquery
+// sub, named alias, which may refer to columns of preceding tables. If on is
+// nil, the join renders as CROSS JOIN LATERAL.
+//
+// Lateral joins are supported by Postgres and MySQL 8.
+func (b SelectBuilder) LateralJoin(sub Subquery, alias string, on Sqlizer) SelectBuilder {
+	kind := "JOIN LATERAL"
+	if on == nil {
+		kind = "CROSS JOIN LATERAL"
+	}
+	return b.JoinClause(joinExpr{kind: kind, target: Alias(sub, alias), on: on})
+}
+
 // Where adds an expression to the WHERE clause of the query.
 //
 // Expressions are ANDed together in the generated SQL.
@@ -284,24 +472,64 @@ func (b SelectBuilder) Where(pred interface{}, args ...interface{}) SelectBuilde
 	if pred == nil || pred == "" {
 		return b
 	}
-	return builder.Append(b, "WhereParts", newWherePart(pred, args...)).(SelectBuilder)
+	b.data.WhereParts = appendCopy(b.data.WhereParts, newWherePart(pred, args...))
+	return b
+}
+
+// WhereStruct adds an expression to the WHERE clause of the query, built from
+// the fields of a filter struct.
+//
+// Each field is mapped to a column using its `sq` tag, and compared using the
+// tag's op option, e.g. `sq:"created_at,op=gte"`. The supported ops are eq (the
+// default), ne, lt, lte, gt, gte, like, notlike, ilike and notilike, rendered
+// as Eq, NotEq, Lt, LtOrEq, Gt, GtOrEq, Like, NotLike, ILike and NotILike
+// respectively. Fields that are nil pointers, zero values, or empty slices or
+// maps are skipped, and the remaining fields are ANDed together in the order
+// they're declared.
+//
+// WhereStruct panics if filter isn't a struct, or uses an unknown op.
+func (b SelectBuilder) WhereStruct(filter interface{}) SelectBuilder {
+	pred, err := newStructWherePart(mapperOrDefault(b.data.Mapper), filter)
+	if err != nil {
+		panic(err)
+	}
+	if pred == nil {
+		return b
+	}
+	return b.Where(pred)
 }
 
 // GroupBy adds GROUP BY expressions to the query.
 func (b SelectBuilder) GroupBy(groupBys ...string) SelectBuilder {
-	return builder.Extend(b, "GroupBys", groupBys).(SelectBuilder)
+	b.data.GroupBys = appendCopy(b.data.GroupBys, groupBys...)
+	return b
 }
 
 // Having adds an expression to the HAVING clause of the query.
 //
 // See Where.
 func (b SelectBuilder) Having(pred interface{}, rest ...interface{}) SelectBuilder {
-	return builder.Append(b, "HavingParts", newWherePart(pred, rest...)).(SelectBuilder)
+	b.data.HavingParts = appendCopy(b.data.HavingParts, newWherePart(pred, rest...))
+	return b
+}
+
+// Window adds a named window to the WINDOW clause of the query, which is
+// rendered after HAVING. Window functions use it with WindowBuilder.Over:
+//
+//	Select("id").
+//		Column(Window("rank()").Over("w")).
+//		Column(Window("avg(salary)").Over("w")).
+//		From("employees").
+//		Window("w", WindowSpec().PartitionBy("dept").OrderBy("salary DESC"))
+func (b SelectBuilder) Window(name string, spec WindowBuilder) SelectBuilder {
+	b.data.Windows = appendCopy[Sqlizer](b.data.Windows, namedWindow{name: name, spec: spec})
+	return b
 }
 
 // OrderByClause adds ORDER BY clause to the query.
 func (b SelectBuilder) OrderByClause(pred interface{}, args ...interface{}) SelectBuilder {
-	return builder.Append(b, "OrderByParts", newPart(pred, args...)).(SelectBuilder)
+	b.data.OrderByParts = appendCopy(b.data.OrderByParts, newPart(pred, args...))
+	return b
 }
 
 // OrderBy adds ORDER BY expressions to the query.
@@ -315,22 +543,77 @@ func (b SelectBuilder) OrderBy(orderBys ...string) SelectBuilder {
 
 // Limit sets a LIMIT clause on the query.
 func (b SelectBuilder) Limit(limit uint64) SelectBuilder {
-	return builder.Set(b, "Limit", fmt.Sprintf("%d", limit)).(SelectBuilder)
+	b.data.Limit = fmt.Sprintf("%d", limit)
+	return b
 }
 
 // Limit ALL allows to access all records with limit
 func (b SelectBuilder) RemoveLimit() SelectBuilder {
-	return builder.Delete(b, "Limit").(SelectBuilder)
+	b.data.Limit = ""
+	return b
 }
 
 // Offset sets a OFFSET clause on the query.
 func (b SelectBuilder) Offset(offset uint64) SelectBuilder {
-	return builder.Set(b, "Offset", fmt.Sprintf("%d", offset)).(SelectBuilder)
+	b.data.Offset = fmt.Sprintf("%d", offset)
+	return b
 }
 
 // RemoveOffset removes OFFSET clause.
 func (b SelectBuilder) RemoveOffset() SelectBuilder {
-	return builder.Delete(b, "Offset").(SelectBuilder)
+	b.data.Offset = ""
+	return b
+}
+
+// ForUpdate adds a FOR UPDATE clause to the query, locking the selected rows
+// against updates and deletes until the end of the transaction. Row locking
+// clauses are rendered after LIMIT and OFFSET, and before any suffixes.
+//
+// Dialects without row locks, such as sqlite, leave the clause out, while
+// those with different locking syntax, such as SQL Server, return an error
+// from ToSql.
+func (b SelectBuilder) ForUpdate() SelectBuilder {
+	b.data.LockStrength = "UPDATE"
+	return b
+}
+
+// ForNoKeyUpdate adds a FOR NO KEY UPDATE clause to the query, a Postgres lock
+// that doesn't block inserts referencing the selected rows.
+//
+// See SelectBuilder.ForUpdate for more information.
+func (b SelectBuilder) ForNoKeyUpdate() SelectBuilder {
+	b.data.LockStrength = "NO KEY UPDATE"
+	return b
+}
+
+// ForShare adds a FOR SHARE clause to the query, locking the selected rows
+// against updates and deletes while allowing other shared locks.
+//
+// See SelectBuilder.ForUpdate for more information.
+func (b SelectBuilder) ForShare() SelectBuilder {
+	b.data.LockStrength = "SHARE"
+	return b
+}
+
+// Of restricts the row locking clause of the query to rows from the given
+// tables.
+func (b SelectBuilder) Of(tables ...string) SelectBuilder {
+	b.data.LockOf = appendCopy(b.data.LockOf, tables...)
+	return b
+}
+
+// SkipLocked adds SKIP LOCKED to the row locking clause of the query, skipping
+// rows that can't be locked immediately.
+func (b SelectBuilder) SkipLocked() SelectBuilder {
+	b.data.LockWait = "SKIP LOCKED"
+	return b
+}
+
+// NoWait adds NOWAIT to the row locking clause of the query, returning an error
+// rather than waiting for rows that can't be locked immediately.
+func (b SelectBuilder) NoWait() SelectBuilder {
+	b.data.LockWait = "NOWAIT"
+	return b
 }
 
 // Suffix adds an expression to the end of the query
@@ -340,5 +623,45 @@ func (b SelectBuilder) Suffix(sql string, args ...interface{}) SelectBuilder {
 
 // SuffixExpr adds an expression to the end of the query
 func (b SelectBuilder) SuffixExpr(expr Sqlizer) SelectBuilder {
-	return builder.Append(b, "Suffixes", expr).(SelectBuilder)
+	b.data.Suffixes = appendCopy(b.data.Suffixes, expr)
+	return b
+}
+
+func structColumns(mapper *Mapper, data interface{}, alias string) []Sqlizer {
+	typ := reflect.TypeOf(data)
+	if typ == nil || reflectx.Deref(typ).Kind() != reflect.Struct {
+		panic(fmt.Errorf("expected a struct, not %T", data))
+	}
+
+	typeMap := mapper.typeMap(typ)
+
+	fields := columnFields(typeMap)
+	columns := make([]Sqlizer, len(fields))
+	for idx, field := range fields {
+		name := field.Path
+		if alias != "" {
+			name = alias + "." + name
+		}
+
+		if strings.Contains(name, ".") {
+			columns[idx] = dottedColumn(name)
+		} else {
+			columns[idx] = newPart(name)
+		}
+	}
+
+	return columns
+}
+
+// dottedColumn is a column added by ColumnsFor with a dotted name, which is
+// selected under that name so it can be scanned into a nested struct field.
+// The name is quoted by the Dialect of the statement it's rendered in.
+type dottedColumn string
+
+func (c dottedColumn) ToSql() (string, []interface{}, error) {
+	return c.toSqlDialect(nil)
+}
+
+func (c dottedColumn) toSqlDialect(d Dialect) (string, []interface{}, error) {
+	return fmt.Sprintf("%s AS %s", string(c), dialectOrDefault(d).QuoteIdent(string(c))), nil, nil
 }
@@ -379,3 +379,156 @@ func TestRemoveColumns(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "SELECT name FROM users", sql)
 }
+
+func TestSelectBuilderWhereStruct(t *testing.T) {
+	filter := struct {
+		Status string `sq:"status"`
+		Name   string `sq:"name,op=like"`
+		MinAge int    `sq:"age,op=gte"`
+	}{Status: "active", MinAge: 21}
+
+	sql, args, err := Select("*").From("users").WhereStruct(filter).Where("deleted_at IS NULL").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE (status = ? AND age >= ?) AND deleted_at IS NULL", sql)
+	assert.Equal(t, []interface{}{"active", 21}, args)
+
+	sql, _, err = Select("*").From("users").WhereStruct(&struct {
+		Status string `sq:"status"`
+	}{}).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users", sql)
+
+	assert.Panics(t, func() { Select("*").WhereStruct("status") })
+}
+
+type columnsForUser struct {
+	Id   int    `sq:"id"`
+	Name string `sq:"name"`
+}
+
+type columnsForPost struct {
+	Id       int    `sq:"id"`
+	Title    string `sq:"title"`
+	Internal string `sq:"-"`
+}
+
+func TestSelectBuilderColumnsFor(t *testing.T) {
+	sql, _, err := Select().ColumnsFor(columnsForUser{}, "").From("users").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM users", sql)
+
+	sql, _, err = Select().
+		ColumnsFor(&columnsForPost{}, "p").
+		ColumnsFor(columnsForUser{}, "u").
+		From("posts p").
+		Join("users u ON u.id = p.author_id").
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := `SELECT p.id AS "p.id", p.title AS "p.title", u.id AS "u.id", u.name AS "u.name" ` +
+		"FROM posts p JOIN users u ON u.id = p.author_id"
+	assert.Equal(t, expectedSql, sql)
+
+	nested := struct {
+		Post   columnsForPost  `sq:"p"`
+		Author *columnsForUser `sq:"u"`
+	}{}
+	sql, _, err = Select().ColumnsFor(nested, "").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT p.id AS "p.id", p.title AS "p.title", u.id AS "u.id", u.name AS "u.name"`, sql)
+
+	assert.Panics(t, func() { Select().ColumnsFor(1, "") })
+}
+
+func TestSelectBuilderColumnsForDialect(t *testing.T) {
+	expected := "SELECT u.id AS `u.id`, u.name AS `u.name` FROM users u"
+
+	sql, _, err := Select().Dialect(MySql).ColumnsFor(columnsForUser{}, "u").From("users u").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sql)
+
+	sql, _, err = Select().ColumnsFor(columnsForUser{}, "u").From("users u").Dialect(MySql).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sql)
+}
+
+func TestSelectBuilderImmutable(t *testing.T) {
+	base := Select("a").From("t").Where("x = ?", 1)
+
+	// both branches append to the same base, and must not see each other's parts
+	left := base.Where("y = ?", 2).Columns("b")
+	right := base.Where("z = ?", 3).Columns("c")
+
+	sql, args, err := left.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a, b FROM t WHERE x = ? AND y = ?", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	sql, args, err = right.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a, c FROM t WHERE x = ? AND z = ?", sql)
+	assert.Equal(t, []interface{}{1, 3}, args)
+
+	sql, _, err = base.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a FROM t WHERE x = ?", sql)
+}
+
+func TestSelectBuilderRowLock(t *testing.T) {
+	jobs := Select("id").From("jobs j").Join("queues q ON q.id = j.queue_id").Where("j.status = ?", "pending").OrderBy("j.id").Limit(10)
+
+	type testCase struct {
+		builder     SelectBuilder
+		expectedSql string
+	}
+
+	cases := []testCase{
+		{jobs.ForUpdate(), "SELECT id FROM jobs j JOIN queues q ON q.id = j.queue_id WHERE j.status = ? ORDER BY j.id LIMIT 10 FOR UPDATE"},
+		{jobs.ForUpdate().SkipLocked(), "SELECT id FROM jobs j JOIN queues q ON q.id = j.queue_id WHERE j.status = ? ORDER BY j.id LIMIT 10 FOR UPDATE SKIP LOCKED"},
+		{jobs.ForNoKeyUpdate().Of("j").NoWait(), "SELECT id FROM jobs j JOIN queues q ON q.id = j.queue_id WHERE j.status = ? ORDER BY j.id LIMIT 10 FOR NO KEY UPDATE OF j NOWAIT"},
+		{jobs.ForShare().Of("j", "q").Offset(5).Suffix("-- ?", 1), "SELECT id FROM jobs j JOIN queues q ON q.id = j.queue_id WHERE j.status = ? ORDER BY j.id LIMIT 10 OFFSET 5 FOR SHARE OF j, q -- ?"},
+	}
+
+	for _, c := range cases {
+		sql, _, err := c.builder.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+	}
+
+	_, _, err := jobs.SkipLocked().ToSql()
+	assert.Error(t, err)
+
+	_, _, err = jobs.Of("j").ToSql()
+	assert.Error(t, err)
+}
+
+func BenchmarkSelectBuilder(b *testing.B) {
+	b.Run("build", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Select("a", "b").
+				From("t").
+				Join("u ON u.id = t.u_id").
+				Where(Eq{"a": i}).
+				Where("b > ?", 2).
+				OrderBy("a").
+				Limit(10)
+		}
+	})
+
+	query := Select("a", "b").
+		From("t").
+		Join("u ON u.id = t.u_id").
+		Where(Eq{"a": 1}).
+		Where("b > ?", 2).
+		OrderBy("a").
+		Limit(10).
+		PlaceholderFormat(Dollar)
+
+	b.Run("tosql", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			query.ToSql()
+		}
+	})
+}
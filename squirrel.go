@@ -4,23 +4,11 @@
 package squirrelly
 
 import (
-	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
-
-	"github.com/jmoiron/sqlx/reflectx"
 )
 
-var mapper *reflectx.Mapper
-
-func getMapper() *reflectx.Mapper {
-	if mapper == nil {
-		mapper = reflectx.NewMapper("sq")
-	}
-
-	return mapper
-}
-
 // Sqlizer is the interface that wraps the ToSql method.
 //
 // ToSql returns a SQL representation of the Sqlizer, along with a slice of args
@@ -30,9 +18,18 @@ type Sqlizer interface {
 }
 
 // rawSqlizer is expected to do what Sqlizer does, but without finalizing placeholders.
-// This is useful for nested queries.
+// This is useful for nested queries, which render with the Dialect d of the
+// statement they're nested in if they don't have one of their own.
 type rawSqlizer interface {
-	toSqlRaw() (string, []interface{}, error)
+	toSqlRaw(d Dialect) (string, []interface{}, error)
+}
+
+// Subquery is a query that can be nested in another statement, such as a
+// SelectBuilder or CompoundBuilder. Its placeholders are numbered along with
+// the rest of the statement.
+type Subquery interface {
+	Sqlizer
+	rawSqlizer
 }
 
 // DebugSqlizer calls ToSql on s and shows the approximate SQL to be executed
@@ -50,47 +47,88 @@ func DebugSqlizer(s Sqlizer) string {
 		return fmt.Sprintf("[ToSql error: %s]", err)
 	}
 
-	var placeholder string
-	downCast, ok := s.(placeholderDebugger)
-	if !ok {
-		placeholder = "?"
-	} else {
-		placeholder = downCast.debugPlaceholder()
-	}
-	// TODO: dedupe this with placeholder.go
-	buf := &bytes.Buffer{}
-	i := 0
-	for {
-		p := strings.Index(sql, placeholder)
-		if p == -1 {
-			break
+	placeholder, syntax := "?", SqlSyntax{}
+	if f, ok := s.(debugFormatter); ok {
+		format, dialect := f.debugFormat()
+		if pd, ok := format.(placeholderDebugger); ok {
+			placeholder = pd.debugPlaceholder()
 		}
-		if len(sql[p:]) > 1 && sql[p:p+2] == "??" { // escape ?? => ?
-			buf.WriteString(sql[:p])
-			buf.WriteString("?")
-			if len(sql[p:]) == 1 {
-				break
+		syntax = dialectOrDefault(dialect).Syntax()
+	}
+
+	buf := &strings.Builder{}
+	used := 0
+	for _, token := range syntax.tokenize(sql) {
+		if !token.code {
+			text := token.text
+			if placeholder == "?" { // positional SQL was unescaped by ToSql
+				text = unescapeLiteral(text)
 			}
-			sql = sql[p+2:]
+			buf.WriteString(text)
+			continue
+		}
+
+		if placeholder == "?" {
+			syntax.scanPlaceholders(token.text, buf, func() {
+				if used < len(args) {
+					fmt.Fprintf(buf, "'%v'", args[used])
+				}
+				used++
+			})
 		} else {
-			if i+1 > len(args) {
-				return fmt.Sprintf(
-					"[DebugSqlizer error: too many placeholders in %#v for %d args]",
-					sql, len(args))
-			}
-			buf.WriteString(sql[:p])
-			fmt.Fprintf(buf, "'%v'", args[i])
-			// advance our sql string "cursor" beyond the arg we placed
-			sql = sql[p+1:]
-			i++
+			used = max(used, writePositionalArgs(token.text, placeholder, args, buf))
+		}
+
+		if used > len(args) {
+			return fmt.Sprintf(
+				"[DebugSqlizer error: too many placeholders in %#v for %d args]",
+				sql, len(args))
 		}
 	}
-	if i < len(args) {
+	if used < len(args) {
 		return fmt.Sprintf(
 			"[DebugSqlizer error: not enough placeholders in %#v for %d args]",
 			sql, len(args))
 	}
-	// "append" any remaning sql that won't need interpolating
-	buf.WriteString(sql)
 	return buf.String()
 }
+
+// debugFormatter is implemented by the statement builders, so DebugSqlizer can
+// find their placeholders in the SQL of their dialect.
+type debugFormatter interface {
+	debugFormat() (PlaceholderFormat, Dialect)
+}
+
+// writePositionalArgs writes code to buf with each positional placeholder,
+// prefix followed by a number, replaced by its arg. It returns the highest
+// placeholder number, leaving placeholders past the end of args in place.
+func writePositionalArgs(code, prefix string, args []interface{}, buf *strings.Builder) int {
+	highest := 0
+	for {
+		p := strings.Index(code, prefix)
+		if p == -1 {
+			break
+		}
+		end := p + len(prefix)
+		for end < len(code) && isDigit(code[end]) {
+			end++
+		}
+		n, err := strconv.Atoi(code[p+len(prefix) : end])
+		if err != nil {
+			buf.WriteString(code[:end])
+			code = code[end:]
+			continue
+		}
+
+		buf.WriteString(code[:p])
+		if n <= len(args) && n > 0 {
+			fmt.Fprintf(buf, "'%v'", args[n-1])
+		} else {
+			buf.WriteString(code[p:end])
+		}
+		highest = max(highest, n)
+		code = code[end:]
+	}
+	buf.WriteString(code)
+	return highest
+}
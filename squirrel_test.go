@@ -112,6 +112,25 @@ func TestDebugSqlizer(t *testing.T) {
 	sqlizer := Expr("x = ? AND y = ? AND z = '??'", 1, "text")
 	expectedDebug := "x = '1' AND y = 'text' AND z = '?'"
 	assert.Equal(t, expectedDebug, DebugSqlizer(sqlizer))
+
+	sqlizer = Expr("x = ? AND z = '?' AND tags ?? 'a'", 1)
+	expectedDebug = "x = '1' AND z = '?' AND tags ? 'a'"
+	assert.Equal(t, expectedDebug, DebugSqlizer(sqlizer))
+
+	b := Select("*").From("t").Where("a = ? AND b = '??'", 1).PlaceholderFormat(Dollar)
+	assert.Equal(t, "SELECT * FROM t WHERE a = '1' AND b = '?'", DebugSqlizer(b))
+}
+
+func TestDebugSqlizerDialect(t *testing.T) {
+	b := Select("*").From("t").Where("a = ? AND b = '?' /* ? */", 1).Where(Eq{"c": "x"})
+	assert.Equal(t, "SELECT * FROM t WHERE a = '1' AND b = '?' /* ? */ AND c = 'x'", DebugSqlizer(b.Dialect(Postgres)))
+	assert.Equal(t, "SELECT * FROM t WHERE a = '1' AND b = '?' /* ? */ AND c = 'x'", DebugSqlizer(b.Dialect(SqlServer)))
+
+	b = Select("*").From("t").Where("tags ?| ? AND body = $$it's ?$$", "{a}").Dialect(Postgres)
+	assert.Equal(t, "SELECT * FROM t WHERE tags ?| '{a}' AND body = $$it's ?$$", DebugSqlizer(b))
+
+	b = Select("*").From("t").Where("a = 'it\\'s ?' AND b = ? # ?", 2).Dialect(MySql)
+	assert.Equal(t, "SELECT * FROM t WHERE a = 'it\\'s ?' AND b = '2' # ?", DebugSqlizer(b))
 }
 
 func TestDebugSqlizerErrors(t *testing.T) {
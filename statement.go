@@ -1,50 +1,116 @@
 package squirrelly
 
-import "github.com/lann/builder"
-
 // StatementBuilderType is the type of StatementBuilder.
-type StatementBuilderType builder.Builder
+type StatementBuilderType struct {
+	placeholderFormat PlaceholderFormat
+	dialect           Dialect
+	mapper            *Mapper
+	whereParts        []Sqlizer
+}
 
 // Select returns a SelectBuilder for this StatementBuilderType.
 func (b StatementBuilderType) Select(columns ...string) SelectBuilder {
-	return SelectBuilder(b).Columns(columns...)
+	return SelectBuilder{data: selectData{
+		PlaceholderFormat: b.placeholderFormat,
+		Dialect:           b.dialect,
+		Mapper:            b.mapper,
+		WhereParts:        b.whereParts,
+	}}.Columns(columns...)
 }
 
 // Insert returns a InsertBuilder for this StatementBuilderType.
 func (b StatementBuilderType) Insert(into string) InsertBuilder {
-	return InsertBuilder(b).Into(into)
+	return InsertBuilder{data: insertData{
+		PlaceholderFormat: b.placeholderFormat,
+		Dialect:           b.dialect,
+		Mapper:            b.mapper,
+	}}.Into(into)
 }
 
 // Replace returns a InsertBuilder for this StatementBuilderType with the
 // statement keyword set to "REPLACE".
 func (b StatementBuilderType) Replace(into string) InsertBuilder {
-	return InsertBuilder(b).statementKeyword("REPLACE").Into(into)
+	return b.Insert(into).statementKeyword("REPLACE")
 }
 
 // Update returns a UpdateBuilder for this StatementBuilderType.
 func (b StatementBuilderType) Update(table string) UpdateBuilder {
-	return UpdateBuilder(b).Table(table)
+	return UpdateBuilder{data: updateData{
+		PlaceholderFormat: b.placeholderFormat,
+		Dialect:           b.dialect,
+		Mapper:            b.mapper,
+		WhereParts:        b.whereParts,
+	}}.Table(table)
 }
 
 // Delete returns a DeleteBuilder for this StatementBuilderType.
 func (b StatementBuilderType) Delete(from string) DeleteBuilder {
-	return DeleteBuilder(b).From(from)
+	return DeleteBuilder{data: deleteData{
+		PlaceholderFormat: b.placeholderFormat,
+		Dialect:           b.dialect,
+		Mapper:            b.mapper,
+		WhereParts:        b.whereParts,
+	}}.From(from)
+}
+
+// Merge returns a MergeBuilder for this StatementBuilderType.
+func (b StatementBuilderType) Merge(into string) MergeBuilder {
+	return MergeBuilder{data: mergeData{
+		PlaceholderFormat: b.placeholderFormat,
+		Dialect:           b.dialect,
+	}}.Into(into)
+}
+
+// Union returns a CompoundBuilder combining queries with UNION for this StatementBuilderType.
+func (b StatementBuilderType) Union(queries ...Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.placeholderFormat, b.dialect, "UNION", false, queries)
+}
+
+// UnionAll returns a CompoundBuilder combining queries with UNION ALL for this StatementBuilderType.
+func (b StatementBuilderType) UnionAll(queries ...Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.placeholderFormat, b.dialect, "UNION", true, queries)
+}
+
+// Intersect returns a CompoundBuilder combining queries with INTERSECT for this StatementBuilderType.
+func (b StatementBuilderType) Intersect(queries ...Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.placeholderFormat, b.dialect, "INTERSECT", false, queries)
+}
+
+// Except returns a CompoundBuilder combining queries with EXCEPT for this StatementBuilderType.
+func (b StatementBuilderType) Except(queries ...Subquery) CompoundBuilder {
+	return newCompoundBuilder(b.placeholderFormat, b.dialect, "EXCEPT", false, queries)
 }
 
 // PlaceholderFormat sets the PlaceholderFormat field for any child builders.
 func (b StatementBuilderType) PlaceholderFormat(f PlaceholderFormat) StatementBuilderType {
-	return builder.Set(b, "PlaceholderFormat", f).(StatementBuilderType)
+	b.placeholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect field for any child builders, along with the
+// dialect's PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b StatementBuilderType) Dialect(d Dialect) StatementBuilderType {
+	b.dialect = d
+	b.placeholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// Mapper sets the Mapper field for any child builders.
+func (b StatementBuilderType) Mapper(m *Mapper) StatementBuilderType {
+	b.mapper = m
+	return b
 }
 
 // Where adds WHERE expressions to the query.
 //
 // See SelectBuilder.Where for more information.
 func (b StatementBuilderType) Where(pred interface{}, args ...interface{}) StatementBuilderType {
-	return builder.Append(b, "WhereParts", newWherePart(pred, args...)).(StatementBuilderType)
+	b.whereParts = appendCopy(b.whereParts, newWherePart(pred, args...))
+	return b
 }
 
 // StatementBuilder is a parent builder for other builders, e.g. SelectBuilder.
-var StatementBuilder = StatementBuilderType(builder.EmptyBuilder).PlaceholderFormat(Question)
+var StatementBuilder = StatementBuilderType{}.PlaceholderFormat(Question)
 
 // Select returns a new SelectBuilder, optionally setting some result columns.
 //
@@ -82,10 +148,45 @@ func Delete(from string) DeleteBuilder {
 	return StatementBuilder.Delete(from)
 }
 
+// Merge returns a new MergeBuilder with the given target table name.
+//
+// See MergeBuilder.Into.
+func Merge(into string) MergeBuilder {
+	return StatementBuilder.Merge(into)
+}
+
+// Union returns a new CompoundBuilder combining queries with UNION.
+//
+// See CompoundBuilder.Union.
+func Union(queries ...Subquery) CompoundBuilder {
+	return StatementBuilder.Union(queries...)
+}
+
+// UnionAll returns a new CompoundBuilder combining queries with UNION ALL.
+//
+// See CompoundBuilder.UnionAll.
+func UnionAll(queries ...Subquery) CompoundBuilder {
+	return StatementBuilder.UnionAll(queries...)
+}
+
+// Intersect returns a new CompoundBuilder combining queries with INTERSECT.
+//
+// See CompoundBuilder.Intersect.
+func Intersect(queries ...Subquery) CompoundBuilder {
+	return StatementBuilder.Intersect(queries...)
+}
+
+// Except returns a new CompoundBuilder combining queries with EXCEPT.
+//
+// See CompoundBuilder.Except.
+func Except(queries ...Subquery) CompoundBuilder {
+	return StatementBuilder.Except(queries...)
+}
+
 // Case returns a new CaseBuilder
 // "what" represents case value
 func Case(what ...interface{}) CaseBuilder {
-	b := CaseBuilder(builder.EmptyBuilder)
+	b := CaseBuilder{}
 
 	switch len(what) {
 	case 0:
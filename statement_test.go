@@ -35,3 +35,39 @@ func TestStatementBuilderWhere(t *testing.T) {
 	expectedArgs := []interface{}{1, 2}
 	assert.Equal(t, expectedArgs, args)
 }
+
+func TestStatementBuilderSharedBase(t *testing.T) {
+	base := StatementBuilder.PlaceholderFormat(Dollar).Where("tenant_id = ?", 1)
+
+	sql, args, err := base.Where("a = ?", 2).Select("x").From("t").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT x FROM t WHERE tenant_id = $1 AND a = $2", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	sql, args, err = base.Delete("t").Where("b = ?", 3).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM t WHERE tenant_id = $1 AND b = $2", sql)
+	assert.Equal(t, []interface{}{1, 3}, args)
+
+	sql, _, err = base.Insert("t").Values(1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO t VALUES ($1)", sql)
+}
+
+func TestZeroValueBuilders(t *testing.T) {
+	sql, _, err := SelectBuilder{}.Columns("a").Where("b = ?", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT a WHERE b = ?", sql)
+
+	sql, _, err = InsertBuilder{}.Into("t").Values(1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO t VALUES (?)", sql)
+
+	sql, _, err = UpdateBuilder{}.Table("t").Set("a", 1).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE t SET a = ?", sql)
+
+	sql, _, err = DeleteBuilder{}.From("t").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM t", sql)
+}
@@ -0,0 +1,195 @@
+package squirrelly
+
+import (
+	"container/list"
+	"database/sql"
+	"sync"
+)
+
+// StatementCacheStats reports the activity of a Db's prepared statement cache.
+type StatementCacheStats struct {
+	// Size is the number of statements currently cached.
+	Size int
+	// Hits counts queries that reused a cached statement.
+	Hits uint64
+	// Misses counts queries that had to prepare a new statement.
+	Misses uint64
+	// Evictions counts statements closed to keep the cache within its size limit.
+	Evictions uint64
+}
+
+// stmtCache is an LRU cache of prepared statements, keyed by their SQL.
+type stmtCache struct {
+	db   *sql.DB
+	size int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   StatementCacheStats
+}
+
+type cachedStmt struct {
+	query string
+	stmt  *sql.Stmt
+
+	// refs counts the queries currently using stmt, so it isn't closed under them when it's evicted
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(db *sql.DB, size int) *stmtCache {
+	return &stmtCache{
+		db:      db,
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// acquire returns the prepared statement for query, preparing and caching it if needed.
+// The statement must be returned with release once the query using it has started.
+func (c *stmtCache) acquire(query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[query]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cachedStmt)
+		entry.refs++
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// prepare outside the lock, so a slow prepare doesn't block cache hits
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[query]; ok {
+		// another query prepared the same statement in the meantime
+		stmt.Close()
+
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cachedStmt)
+		entry.refs++
+		return entry, nil
+	}
+
+	entry := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+
+	return entry, nil
+}
+
+// release returns a statement acquired from the cache, closing it if it was evicted while in use.
+func (c *stmtCache) release(entry *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// evict removes an element from the cache, closing its statement unless it's in use. c.mu must be held.
+func (c *stmtCache) evict(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cachedStmt)
+	delete(c.entries, entry.query)
+	c.stats.Evictions++
+
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// close closes every cached statement.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.lru.Front(); elem != nil; elem = c.lru.Front() {
+		entry := c.lru.Remove(elem).(*cachedStmt)
+		delete(c.entries, entry.query)
+
+		entry.evicted = true
+		if entry.refs == 0 {
+			entry.stmt.Close()
+		}
+	}
+}
+
+func (c *stmtCache) getStats() StatementCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// cachedQuerier is a Querier that runs queries through the statements of a stmtCache,
+// rebinding them to tx when it's set.
+type cachedQuerier struct {
+	cache *stmtCache
+	tx    *sql.Tx
+}
+
+// stmt returns the statement to run query with. Statements bound to a transaction are closed when it ends.
+func (q cachedQuerier) stmt(query string) (*sql.Stmt, func(), error) {
+	entry, err := q.cache.acquire(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	release := func() { q.cache.release(entry) }
+	if q.tx != nil {
+		return q.tx.Stmt(entry.stmt), release, nil
+	}
+	return entry.stmt, release, nil
+}
+
+func (q cachedQuerier) Exec(query string, args ...any) (sql.Result, error) {
+	stmt, release, err := q.stmt(query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return stmt.Exec(args...)
+}
+
+func (q cachedQuerier) Query(query string, args ...any) (*sql.Rows, error) {
+	stmt, release, err := q.stmt(query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return stmt.Query(args...)
+}
+
+func (q cachedQuerier) QueryRow(query string, args ...any) *sql.Row {
+	stmt, release, err := q.stmt(query)
+	if err != nil {
+		// *sql.Row can't be built with an error, so let the query report it
+		if q.tx != nil {
+			return q.tx.QueryRow(query, args...)
+		}
+		return q.cache.db.QueryRow(query, args...)
+	}
+	defer release()
+
+	return stmt.QueryRow(args...)
+}
@@ -0,0 +1,146 @@
+package squirrelly
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func openStmtCacheDb(t *testing.T, size int) *Db {
+	db, err := Open("sqlite", "file::memory:", WithStatementCache(size))
+	assert.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	_, err = db.DB.Exec("CREATE TABLE foo (pk INTEGER PRIMARY KEY, comment TEXT NOT NULL)")
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestStmtCacheLRU(t *testing.T) {
+	db := openStmtCacheDb(t, 2)
+	defer db.Close()
+
+	first, err := db.stmts.acquire("SELECT 1")
+	assert.NoError(t, err)
+	db.stmts.release(first)
+
+	second, err := db.stmts.acquire("SELECT 2")
+	assert.NoError(t, err)
+	db.stmts.release(second)
+
+	again, err := db.stmts.acquire("SELECT 1")
+	assert.NoError(t, err)
+	assert.Same(t, first, again)
+	db.stmts.release(again)
+
+	// SELECT 2 is now the least recently used, and is evicted and closed
+	third, err := db.stmts.acquire("SELECT 3")
+	assert.NoError(t, err)
+	db.stmts.release(third)
+
+	assert.True(t, second.evicted)
+	_, err = second.stmt.Exec()
+	assert.EqualError(t, err, "sql: statement is closed")
+
+	assert.Equal(t, StatementCacheStats{Size: 2, Hits: 1, Misses: 3, Evictions: 1}, db.StatementCacheStats())
+}
+
+func TestStmtCacheEvictInUse(t *testing.T) {
+	db := openStmtCacheDb(t, 1)
+	defer db.Close()
+
+	inUse, err := db.stmts.acquire("SELECT 1")
+	assert.NoError(t, err)
+
+	other, err := db.stmts.acquire("SELECT 2")
+	assert.NoError(t, err)
+	db.stmts.release(other)
+
+	// evicted statements stay open until they're released
+	assert.True(t, inUse.evicted)
+	var one int
+	assert.NoError(t, inUse.stmt.QueryRow().Scan(&one))
+
+	db.stmts.release(inUse)
+	_, err = inUse.stmt.Exec()
+	assert.EqualError(t, err, "sql: statement is closed")
+}
+
+func TestDbStatementCache(t *testing.T) {
+	db := openStmtCacheDb(t, 10)
+	defer db.Close()
+
+	type foo struct {
+		Pk      int    `sq:"pk"`
+		Comment string `sq:"comment"`
+	}
+
+	for i := 1; i <= 3; i++ {
+		_, err := db.Exec(Insert("foo").Columns("pk", "comment").Values(i, "comment"))
+		assert.NoError(t, err)
+	}
+
+	query := Select("*").From("foo").Where(Eq{"pk": 2})
+	record := foo{}
+	assert.NoError(t, db.Get(query, &record))
+	assert.Equal(t, foo{Pk: 2, Comment: "comment"}, record)
+
+	records := []foo{}
+	assert.NoError(t, db.GetAll(query, &records))
+
+	var count int
+	assert.NoError(t, db.QueryRow(Select("COUNT(*)").From("foo")).Scan(&count))
+	assert.Equal(t, 3, count)
+
+	assert.NoError(t, db.WithTx(func(tx DbLike) error {
+		_, err := tx.Exec(Insert("foo").Columns("pk", "comment").Values(4, "comment"))
+		if err != nil {
+			return err
+		}
+		return tx.Get(query, &record)
+	}))
+
+	assert.Equal(t, StatementCacheStats{Size: 3, Hits: 5, Misses: 3}, db.StatementCacheStats())
+
+	// prepare errors are returned from the query, or from the row for QueryRow
+	_, err := db.Exec(Select("*").From("missing"))
+	assert.Error(t, err)
+	assert.Error(t, db.QueryRow(Select("*").From("missing")).Scan(&count))
+}
+
+func TestDbStatementCacheDisabled(t *testing.T) {
+	db, _ := Open("sqlite", "file::memory:")
+	defer db.Close()
+
+	var one int
+	assert.NoError(t, db.QueryRow(Select("1")).Scan(&one))
+	assert.Equal(t, StatementCacheStats{}, db.StatementCacheStats())
+}
+
+func TestDbStatementCacheConcurrency(t *testing.T) {
+	db, err := Open("sqlite", "file:stmtcache?mode=memory&cache=shared", WithStatementCache(2))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// five distinct statements compete for two cache slots
+			var value int
+			assert.NoError(t, db.QueryRow(Select(strconv.Itoa(i%5))).Scan(&value))
+			assert.Equal(t, i%5, value)
+		}(i)
+	}
+	wg.Wait()
+
+	stats := db.StatementCacheStats()
+	assert.Equal(t, uint64(50), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Size, 2)
+}
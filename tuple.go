@@ -0,0 +1,250 @@
+package squirrelly
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TupleBuilder builds comparisons of several columns at once, as a row value,
+// for composite keys and keyset pagination:
+//
+//	Select("*").
+//		From("events").
+//		Where(Tuple("created_at", "id").Gt(lastCreatedAt, lastId)).
+//		OrderBy("created_at", "id").
+//		Limit(50)
+//
+// renders WHERE (created_at, id) > (?, ?). If the Dialect of the statement
+// doesn't support row values, as with SqlServer, comparisons are expanded into
+// ANDed and ORed comparisons of each column, such as
+// (created_at > ? OR (created_at = ? AND id > ?)).
+//
+// Values are bound to placeholders, unless they're a Sqlizer, such as a Col.
+type TupleBuilder struct {
+	columns []string
+}
+
+// Tuple returns a TupleBuilder comparing columns.
+func Tuple(columns ...string) TupleBuilder {
+	return TupleBuilder{columns: columns}
+}
+
+// Eq compares the columns to values with =.
+func (b TupleBuilder) Eq(values ...interface{}) Sqlizer {
+	return b.compare("=", values)
+}
+
+// NotEq compares the columns to values with <>.
+func (b TupleBuilder) NotEq(values ...interface{}) Sqlizer {
+	return b.compare("<>", values)
+}
+
+// Lt compares the columns to values with <, ordering rows by their first
+// column, then their second, and so on.
+func (b TupleBuilder) Lt(values ...interface{}) Sqlizer {
+	return b.compare("<", values)
+}
+
+// LtOrEq compares the columns to values with <=.
+//
+// See TupleBuilder.Lt for more information.
+func (b TupleBuilder) LtOrEq(values ...interface{}) Sqlizer {
+	return b.compare("<=", values)
+}
+
+// Gt compares the columns to values with >.
+//
+// See TupleBuilder.Lt for more information.
+func (b TupleBuilder) Gt(values ...interface{}) Sqlizer {
+	return b.compare(">", values)
+}
+
+// GtOrEq compares the columns to values with >=.
+//
+// See TupleBuilder.Lt for more information.
+func (b TupleBuilder) GtOrEq(values ...interface{}) Sqlizer {
+	return b.compare(">=", values)
+}
+
+// In matches the columns against rows of values, each with one value per
+// column. Like Eq, an empty list of rows matches nothing, rendering (1=0).
+func (b TupleBuilder) In(rows [][]interface{}) Sqlizer {
+	return tupleIn{tuple: b, rows: rows}
+}
+
+// NotIn matches the columns against none of the rows of values. An empty list
+// of rows matches everything, rendering (1=1).
+func (b TupleBuilder) NotIn(rows [][]interface{}) Sqlizer {
+	return tupleIn{tuple: b, rows: rows, not: true}
+}
+
+func (b TupleBuilder) compare(opr string, values []interface{}) Sqlizer {
+	return tupleComparison{tuple: b, opr: opr, values: values}
+}
+
+func (b TupleBuilder) validate(values []interface{}) error {
+	if len(b.columns) == 0 {
+		return errors.New("tuples must have at least one column")
+	}
+	if len(values) != len(b.columns) {
+		return fmt.Errorf("tuple of %d columns compared to %d values", len(b.columns), len(values))
+	}
+	return nil
+}
+
+type tupleComparison struct {
+	tuple  TupleBuilder
+	opr    string
+	values []interface{}
+}
+
+func (c tupleComparison) ToSql() (string, []interface{}, error) {
+	return c.toSqlDialect(nil)
+}
+
+func (c tupleComparison) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	if err = c.tuple.validate(c.values); err != nil {
+		return
+	}
+
+	valueSqls, valueArgs, err := tupleValuesSql(c.values, d)
+	if err != nil {
+		return
+	}
+
+	if dialectOrDefault(d).RowValues() {
+		sql = fmt.Sprintf("(%s) %s (%s)", strings.Join(c.tuple.columns, ", "), c.opr, strings.Join(valueSqls, ", "))
+		return sql, flattenArgs(valueArgs), nil
+	}
+
+	switch c.opr {
+	case "=":
+		return tupleConj(c.tuple.columns, "=", valueSqls, " AND "), flattenArgs(valueArgs), nil
+	case "<>":
+		return tupleConj(c.tuple.columns, "<>", valueSqls, " OR "), flattenArgs(valueArgs), nil
+	}
+
+	// expand into a lexicographic ordering: the first column that differs
+	// decides, and only the last column is compared with <= or >=
+	strict := c.opr[:1]
+	columns := c.tuple.columns
+
+	terms := make([]string, len(columns))
+	for idx := range columns {
+		comparisons := make([]string, 0, idx+1)
+		for prev := 0; prev < idx; prev++ {
+			comparisons = append(comparisons, fmt.Sprintf("%s = %s", columns[prev], valueSqls[prev]))
+			args = append(args, valueArgs[prev]...)
+		}
+
+		termOpr := strict
+		if idx == len(columns)-1 {
+			termOpr = c.opr
+		}
+		comparisons = append(comparisons, fmt.Sprintf("%s %s %s", columns[idx], termOpr, valueSqls[idx]))
+		args = append(args, valueArgs[idx]...)
+
+		if len(comparisons) > 1 {
+			terms[idx] = fmt.Sprintf("(%s)", strings.Join(comparisons, " AND "))
+		} else {
+			terms[idx] = comparisons[0]
+		}
+	}
+
+	sql = fmt.Sprintf("(%s)", strings.Join(terms, " OR "))
+	return
+}
+
+// tupleValuesSql renders each of values for dialect d, along with its args.
+func tupleValuesSql(values []interface{}, d Dialect) ([]string, [][]interface{}, error) {
+	valueSqls := make([]string, len(values))
+	valueArgs := make([][]interface{}, len(values))
+	for idx, value := range values {
+		var err error
+		valueSqls[idx], valueArgs[idx], err = operandSql(value, d)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return valueSqls, valueArgs, nil
+}
+
+func flattenArgs(valueArgs [][]interface{}) (args []interface{}) {
+	for _, a := range valueArgs {
+		args = append(args, a...)
+	}
+	return args
+}
+
+// tupleConj compares each column to its value with opr, joining the
+// comparisons with sep.
+func tupleConj(columns []string, opr string, valueSqls []string, sep string) string {
+	comparisons := make([]string, len(columns))
+	for idx, column := range columns {
+		comparisons[idx] = fmt.Sprintf("%s %s %s", column, opr, valueSqls[idx])
+	}
+	return fmt.Sprintf("(%s)", strings.Join(comparisons, sep))
+}
+
+type tupleIn struct {
+	tuple TupleBuilder
+	rows  [][]interface{}
+	not   bool
+}
+
+func (in tupleIn) ToSql() (string, []interface{}, error) {
+	return in.toSqlDialect(nil)
+}
+
+func (in tupleIn) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
+	if len(in.rows) == 0 {
+		if len(in.tuple.columns) == 0 {
+			err = errors.New("tuples must have at least one column")
+			return
+		}
+
+		// match Eq's handling of empty lists
+		if in.not {
+			return sqlTrue, []interface{}{}, nil
+		}
+		return sqlFalse, []interface{}{}, nil
+	}
+
+	rowValues := dialectOrDefault(d).RowValues()
+	rowSqls := make([]string, len(in.rows))
+	for r, row := range in.rows {
+		if err = in.tuple.validate(row); err != nil {
+			return
+		}
+
+		var valueSqls []string
+		var valueArgs [][]interface{}
+		valueSqls, valueArgs, err = tupleValuesSql(row, d)
+		if err != nil {
+			return
+		}
+		args = append(args, flattenArgs(valueArgs)...)
+
+		switch {
+		case rowValues:
+			rowSqls[r] = fmt.Sprintf("(%s)", strings.Join(valueSqls, ", "))
+		case in.not:
+			rowSqls[r] = tupleConj(in.tuple.columns, "<>", valueSqls, " OR ")
+		default:
+			rowSqls[r] = tupleConj(in.tuple.columns, "=", valueSqls, " AND ")
+		}
+	}
+
+	switch {
+	case rowValues && in.not:
+		sql = fmt.Sprintf("(%s) NOT IN (%s)", strings.Join(in.tuple.columns, ", "), strings.Join(rowSqls, ", "))
+	case rowValues:
+		sql = fmt.Sprintf("(%s) IN (%s)", strings.Join(in.tuple.columns, ", "), strings.Join(rowSqls, ", "))
+	case in.not:
+		sql = fmt.Sprintf("(%s)", strings.Join(rowSqls, " AND "))
+	default:
+		sql = fmt.Sprintf("(%s)", strings.Join(rowSqls, " OR "))
+	}
+	return
+}
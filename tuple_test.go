@@ -0,0 +1,119 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTupleToSql(t *testing.T) {
+	type testCase struct {
+		pred         Sqlizer
+		expectedSql  string
+		expectedArgs []interface{}
+	}
+
+	tuple := Tuple("a", "b")
+	expanded := Tuple("a", "b", "c")
+
+	cases := []testCase{
+		{tuple.Eq(1, 2), "(a, b) = (?, ?)", []interface{}{1, 2}},
+		{tuple.NotEq(1, 2), "(a, b) <> (?, ?)", []interface{}{1, 2}},
+		{tuple.Lt(1, Col("x.b")), "(a, b) < (?, x.b)", []interface{}{1}},
+		{tuple.GtOrEq(1, 2), "(a, b) >= (?, ?)", []interface{}{1, 2}},
+		{tuple.In([][]interface{}{{1, 2}, {3, 4}}), "(a, b) IN ((?, ?), (?, ?))", []interface{}{1, 2, 3, 4}},
+		{tuple.NotIn([][]interface{}{{1, 2}}), "(a, b) NOT IN ((?, ?))", []interface{}{1, 2}},
+		{tuple.In(nil), "(1=0)", []interface{}{}},
+		{tuple.NotIn([][]interface{}{}), "(1=1)", []interface{}{}},
+	}
+
+	for _, c := range cases {
+		sql, args, err := c.pred.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+		assert.Equal(t, c.expectedArgs, args)
+	}
+
+	// rendered for a dialect without row values
+	expandedCases := []testCase{
+		{expanded.Eq(1, 2, 3), "(a = ? AND b = ? AND c = ?)", []interface{}{1, 2, 3}},
+		{expanded.NotEq(1, 2, 3), "(a <> ? OR b <> ? OR c <> ?)", []interface{}{1, 2, 3}},
+		{
+			expanded.Gt(1, 2, 3),
+			"(a > ? OR (a = ? AND b > ?) OR (a = ? AND b = ? AND c > ?))",
+			[]interface{}{1, 1, 2, 1, 2, 3},
+		},
+		{
+			expanded.LtOrEq(1, Expr("?+1", 2), 3),
+			"(a < ? OR (a = ? AND b < ?+1) OR (a = ? AND b = ?+1 AND c <= ?))",
+			[]interface{}{1, 1, 2, 1, 2, 3},
+		},
+		{
+			expanded.In([][]interface{}{{1, 2, 3}, {4, 5, 6}}),
+			"((a = ? AND b = ? AND c = ?) OR (a = ? AND b = ? AND c = ?))",
+			[]interface{}{1, 2, 3, 4, 5, 6},
+		},
+		{
+			expanded.NotIn([][]interface{}{{1, 2, 3}, {4, 5, 6}}),
+			"((a <> ? OR b <> ? OR c <> ?) AND (a <> ? OR b <> ? OR c <> ?))",
+			[]interface{}{1, 2, 3, 4, 5, 6},
+		},
+		{expanded.In(nil), "(1=0)", []interface{}{}},
+	}
+
+	for _, c := range expandedCases {
+		sql, args, err := nestedToSql(c.pred, SqlServer)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+		assert.Equal(t, c.expectedArgs, args)
+	}
+}
+
+func TestTupleInBuilders(t *testing.T) {
+	sql, args, err := Select("id").
+		From("events").
+		Where("kind = ?", "click").
+		Where(Tuple("created_at", "id").Gt("2024-01-01", 10)).
+		Dialect(SqlServer).
+		OrderBy("created_at", "id").
+		Limit(5).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT id FROM events WHERE kind = @p1 " +
+		"AND (created_at > @p2 OR (created_at = @p3 AND id > @p4)) " +
+		"ORDER BY created_at, id OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{"click", "2024-01-01", "2024-01-01", 10}, args)
+
+	sql, args, err = Select("id").
+		From("events").
+		Where(Or{Not(Tuple("a", "b").Eq(1, 2)), Eq{"c": 3}}).
+		Dialect(SqlServer).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM events WHERE (NOT ((a = @p1 AND b = @p2)) OR c = @p3)", sql)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	sql, args, err = StatementBuilder.Dialect(Postgres).
+		Delete("events").
+		Where(Tuple("a", "b").In([][]interface{}{{1, 2}})).
+		ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM events WHERE (a, b) IN (($1, $2))", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
+
+func TestTupleToSqlErr(t *testing.T) {
+	_, _, err := Tuple("a", "b").Eq(1).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Tuple().Gt().ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Tuple("a", "b").In([][]interface{}{{1, 2}, {3}}).ToSql()
+	assert.Error(t, err)
+
+	_, _, err = Tuple().In(nil).ToSql()
+	assert.Error(t, err)
+}
@@ -6,20 +6,23 @@ import (
 	"reflect"
 	"sort"
 	"strings"
-
-	"github.com/lann/builder"
 )
 
 type updateData struct {
 	PlaceholderFormat PlaceholderFormat
+	Dialect           Dialect
+	Mapper            *Mapper
 	Prefixes          []Sqlizer
+	With              []commonTableExpr
 	Table             string
 	SetClauses        []setClause
-	From              Sqlizer
+	From              []Sqlizer
+	Joins             []Sqlizer
 	WhereParts        []Sqlizer
 	OrderBys          []string
 	Limit             string
 	Offset            string
+	Returning         []string
 	Suffixes          []Sqlizer
 }
 
@@ -38,10 +41,37 @@ func (d *updateData) ToSql() (sqlStr string, args []interface{}, err error) {
 		return
 	}
 
+	multiTable := len(d.From) > 0 || len(d.Joins) > 0
+	var multiTableStyle MultiTableStyle
+	if multiTable {
+		multiTableStyle, err = dialectOrDefault(d.Dialect).MultiTable(UpdateStatement)
+		if err != nil {
+			return
+		}
+		if multiTableStyle == MultiTableFrom && len(d.From) == 0 {
+			err = fmt.Errorf("update statements with joins must have a From table to join to")
+			return
+		}
+	}
+
+	limit, top, err := dialectOrDefault(d.Dialect).UpdateLimit(d.Limit, d.Offset)
+	if err != nil {
+		return
+	}
+
+	var returning string
+	var output bool
+	if len(d.Returning) > 0 {
+		returning, output, err = dialectOrDefault(d.Dialect).Returning(d.Returning, "INSERTED")
+		if err != nil {
+			return
+		}
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
-		args, err = appendToSql(d.Prefixes, sql, " ", args)
+		args, err = appendToSql(d.Prefixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
@@ -49,19 +79,40 @@ func (d *updateData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(" ")
 	}
 
+	args, err = appendWithToSql(d.With, d.Dialect, sql, args)
+	if err != nil {
+		return
+	}
+
 	sql.WriteString("UPDATE ")
+	if top {
+		sql.WriteString(limit)
+		sql.WriteString(" ")
+	}
 	sql.WriteString(d.Table)
 
+	if multiTable && multiTableStyle == MultiTableJoin {
+		if len(d.From) > 0 {
+			sql.WriteString(", ")
+		} else {
+			sql.WriteString(" ")
+		}
+		args, err = appendTablesToSql(d.From, d.Joins, d.Dialect, sql, args)
+		if err != nil {
+			return
+		}
+	}
+
 	sql.WriteString(" SET ")
 	setSqls := make([]string, len(d.SetClauses))
 	for i, setClause := range d.SetClauses {
 		var valSql string
 		if vs, ok := setClause.value.(Sqlizer); ok {
-			vsql, vargs, err := vs.ToSql()
+			vsql, vargs, err := nestedToSql(vs, d.Dialect)
 			if err != nil {
 				return "", nil, err
 			}
-			if _, ok := vs.(SelectBuilder); ok {
+			if _, ok := vs.(Subquery); ok {
 				valSql = fmt.Sprintf("(%s)", vsql)
 			} else {
 				valSql = vsql
@@ -75,9 +126,14 @@ func (d *updateData) ToSql() (sqlStr string, args []interface{}, err error) {
 	}
 	sql.WriteString(strings.Join(setSqls, ", "))
 
-	if d.From != nil {
+	if output {
+		sql.WriteString(" ")
+		sql.WriteString(returning)
+	}
+
+	if multiTable && multiTableStyle == MultiTableFrom {
 		sql.WriteString(" FROM ")
-		args, err = appendToSql([]Sqlizer{d.From}, sql, "", args)
+		args, err = appendTablesToSql(d.From, d.Joins, d.Dialect, sql, args)
 		if err != nil {
 			return
 		}
@@ -85,7 +141,7 @@ func (d *updateData) ToSql() (sqlStr string, args []interface{}, err error) {
 
 	if len(d.WhereParts) > 0 {
 		sql.WriteString(" WHERE ")
-		args, err = appendToSql(d.WhereParts, sql, " AND ", args)
+		args, err = appendToSql(d.WhereParts, d.Dialect, sql, " AND ", args)
 		if err != nil {
 			return
 		}
@@ -96,35 +152,33 @@ func (d *updateData) ToSql() (sqlStr string, args []interface{}, err error) {
 		sql.WriteString(strings.Join(d.OrderBys, ", "))
 	}
 
-	if len(d.Limit) > 0 {
-		sql.WriteString(" LIMIT ")
-		sql.WriteString(d.Limit)
+	if limit != "" && !top {
+		sql.WriteString(" ")
+		sql.WriteString(limit)
 	}
 
-	if len(d.Offset) > 0 {
-		sql.WriteString(" OFFSET ")
-		sql.WriteString(d.Offset)
+	if returning != "" && !output {
+		sql.WriteString(" ")
+		sql.WriteString(returning)
 	}
 
 	if len(d.Suffixes) > 0 {
 		sql.WriteString(" ")
-		args, err = appendToSql(d.Suffixes, sql, " ", args)
+		args, err = appendToSql(d.Suffixes, d.Dialect, sql, " ", args)
 		if err != nil {
 			return
 		}
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, d.Dialect, sql.String())
 	return
 }
 
 // Builder
 
 // UpdateBuilder builds SQL UPDATE statements.
-type UpdateBuilder builder.Builder
-
-func init() {
-	builder.Register(UpdateBuilder{}, updateData{})
+type UpdateBuilder struct {
+	data updateData
 }
 
 // Format methods
@@ -132,15 +186,34 @@ func init() {
 // PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
 // query.
 func (b UpdateBuilder) PlaceholderFormat(f PlaceholderFormat) UpdateBuilder {
-	return builder.Set(b, "PlaceholderFormat", f).(UpdateBuilder)
+	b.data.PlaceholderFormat = f
+	return b
+}
+
+// Dialect sets the Dialect used to render the query, along with its
+// PlaceholderFormat. A nil Dialect renders the default SQL again.
+func (b UpdateBuilder) Dialect(d Dialect) UpdateBuilder {
+	b.data.Dialect = d
+	b.data.PlaceholderFormat = dialectOrDefault(d).PlaceholderFormat()
+	return b
+}
+
+// Mapper sets the Mapper used to map struct fields to columns, instead of the
+// package level default.
+func (b UpdateBuilder) Mapper(m *Mapper) UpdateBuilder {
+	b.data.Mapper = m
+	return b
 }
 
 // SQL methods
 
 // ToSql builds the query into a SQL string and bound args.
 func (b UpdateBuilder) ToSql() (string, []interface{}, error) {
-	data := builder.GetStruct(b).(updateData)
-	return data.ToSql()
+	return b.data.ToSql()
+}
+
+func (b UpdateBuilder) debugFormat() (PlaceholderFormat, Dialect) {
+	return b.data.PlaceholderFormat, b.data.Dialect
 }
 
 // MustSql builds the query into a SQL string and bound args.
@@ -160,17 +233,49 @@ func (b UpdateBuilder) Prefix(sql string, args ...interface{}) UpdateBuilder {
 
 // PrefixExpr adds an expression to the very beginning of the query
 func (b UpdateBuilder) PrefixExpr(expr Sqlizer) UpdateBuilder {
-	return builder.Append(b, "Prefixes", expr).(UpdateBuilder)
+	b.data.Prefixes = appendCopy(b.data.Prefixes, expr)
+	return b
+}
+
+// With adds a common table expression to the WITH clause of the query.
+//
+// See SelectBuilder.With for more information.
+func (b UpdateBuilder) With(name string, query Subquery) UpdateBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression to the WITH clause
+// of the query.
+//
+// See SelectBuilder.WithRecursive for more information.
+func (b UpdateBuilder) WithRecursive(name string, columns []string, query Subquery) UpdateBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, columns: columns, query: query, recursive: true})
+	return b
+}
+
+// WithMaterialized adds a materialized common table expression to the WITH
+// clause of the query.
+//
+// See SelectBuilder.WithMaterialized for more information.
+func (b UpdateBuilder) WithMaterialized(name string, query Subquery) UpdateBuilder {
+	b.data.With = appendCopy(b.data.With, commonTableExpr{name: name, query: query, materialized: true})
+	return b
 }
 
 // Table sets the table to be updated.
 func (b UpdateBuilder) Table(table string) UpdateBuilder {
-	return builder.Set(b, "Table", table).(UpdateBuilder)
+	b.data.Table = table
+	return b
 }
 
 // Set adds SET clauses to the query.
+//
+// A Sqlizer value, such as an Expr or a Col referring to another column, is
+// rendered in place of a placeholder, and subqueries are parenthesised.
 func (b UpdateBuilder) Set(column string, value interface{}) UpdateBuilder {
-	return builder.Append(b, "SetClauses", setClause{column: column, value: value}).(UpdateBuilder)
+	b.data.SetClauses = appendCopy(b.data.SetClauses, setClause{column: column, value: value})
+	return b
 }
 
 // SetMap is a convenience method which calls .Set for each key/value pair in clauses.
@@ -197,53 +302,132 @@ func (b UpdateBuilder) SetStruct(data interface{}, columns ...string) UpdateBuil
 		return b
 	}
 
-	mapper := getMapper()
-	lookup := mapper.FieldMap(reflect.ValueOf(data))
+	mapper := mapperOrDefault(b.data.Mapper)
+	value := reflect.ValueOf(data)
 
 	for _, column := range columns {
-		value, hasValue := lookup[column]
+		field, hasValue := mapper.fieldByColumn(value, column)
 		if !hasValue {
-			panic(fmt.Errorf("missing column `%[1]s` in struct. Is it tagged with `sq:\"%[1]s\"`?", column))
+			panic(mapper.missingColumnError(column))
 		}
 
-		b = b.Set(column, value.Interface())
+		b = b.Set(column, fieldInterface(field))
 	}
 	return b
 }
 
-// From adds FROM clause to the query
-// FROM is valid construct in postgresql only.
+// SetDiff sets values for an update builder from the modified struct, using only the columns that differ from the original struct.
+//
+// This is a convenience method that calls .SetStruct with the columns returned by [Diff]. Like SetStruct, it panics if the structs can't be compared.
+// If nothing changed no Set clauses are added, and ToSql will return an error; use [Diff] directly to check for changes beforehand.
+func (b UpdateBuilder) SetDiff(original, modified interface{}) UpdateBuilder {
+	columns, err := diff(mapperOrDefault(b.data.Mapper), original, modified)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(columns) == 0 {
+		return b
+	}
+
+	return b.SetStruct(modified, columns...)
+}
+
+// From adds a table to the FROM clause of the query, which lists the tables
+// the update reads from besides its target table. Calling From more than once
+// adds more tables.
+//
+// The tables and joins of the query are rendered as the builder's Dialect
+// expects: in a FROM clause after SET, or MySQL style, alongside the target
+// table as in UPDATE t, tables JOIN ... SET.
 func (b UpdateBuilder) From(from string) UpdateBuilder {
-	return builder.Set(b, "From", newPart(from)).(UpdateBuilder)
+	b.data.From = appendCopy(b.data.From, newPart(from))
+	return b
 }
 
-// FromSelect sets a subquery into the FROM clause of the query.
-func (b UpdateBuilder) FromSelect(from SelectBuilder, alias string) UpdateBuilder {
-	// Prevent misnumbered parameters in nested selects (#183).
-	from = from.PlaceholderFormat(Question)
-	return builder.Set(b, "From", Alias(from, alias)).(UpdateBuilder)
+// FromSelect adds a subquery to the FROM clause of the query.
+func (b UpdateBuilder) FromSelect(from Subquery, alias string) UpdateBuilder {
+	b.data.From = appendCopy(b.data.From, Sqlizer(Alias(from, alias)))
+	return b
+}
+
+// JoinClause adds a join clause to the query.
+//
+// See UpdateBuilder.From for how joins are rendered.
+func (b UpdateBuilder) JoinClause(pred interface{}, args ...interface{}) UpdateBuilder {
+	b.data.Joins = appendCopy(b.data.Joins, newPart(pred, args...))
+	return b
+}
+
+// Join adds a JOIN clause to the query.
+func (b UpdateBuilder) Join(join string, rest ...interface{}) UpdateBuilder {
+	return b.JoinClause("JOIN "+join, rest...)
+}
+
+// LeftJoin adds a LEFT JOIN clause to the query.
+func (b UpdateBuilder) LeftJoin(join string, rest ...interface{}) UpdateBuilder {
+	return b.JoinClause("LEFT JOIN "+join, rest...)
+}
+
+// JoinOn adds a JOIN clause to the query, joining table on the condition on.
+func (b UpdateBuilder) JoinOn(table string, on Sqlizer) UpdateBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: newPart(table), on: on})
+}
+
+// JoinSelect adds a JOIN clause to the query, joining the subquery sub, named
+// alias, on the condition on.
+func (b UpdateBuilder) JoinSelect(sub Subquery, alias string, on Sqlizer) UpdateBuilder {
+	return b.JoinClause(joinExpr{kind: "JOIN", target: Alias(sub, alias), on: on})
 }
 
 // Where adds WHERE expressions to the query.
 //
 // See SelectBuilder.Where for more information.
 func (b UpdateBuilder) Where(pred interface{}, args ...interface{}) UpdateBuilder {
-	return builder.Append(b, "WhereParts", newWherePart(pred, args...)).(UpdateBuilder)
+	b.data.WhereParts = appendCopy(b.data.WhereParts, newWherePart(pred, args...))
+	return b
+}
+
+// WhereStruct adds an expression to the WHERE clause of the query, built from
+// the fields of a filter struct.
+//
+// See SelectBuilder.WhereStruct for more information.
+func (b UpdateBuilder) WhereStruct(filter interface{}) UpdateBuilder {
+	pred, err := newStructWherePart(mapperOrDefault(b.data.Mapper), filter)
+	if err != nil {
+		panic(err)
+	}
+	if pred == nil {
+		return b
+	}
+	return b.Where(pred)
 }
 
 // OrderBy adds ORDER BY expressions to the query.
 func (b UpdateBuilder) OrderBy(orderBys ...string) UpdateBuilder {
-	return builder.Extend(b, "OrderBys", orderBys).(UpdateBuilder)
+	b.data.OrderBys = appendCopy(b.data.OrderBys, orderBys...)
+	return b
 }
 
-// Limit sets a LIMIT clause on the query.
+// Limit sets a LIMIT clause on the query, or a TOP clause with SqlServer.
+// Postgres doesn't limit the rows of update and delete statements, so it's an
+// error with that Dialect.
 func (b UpdateBuilder) Limit(limit uint64) UpdateBuilder {
-	return builder.Set(b, "Limit", fmt.Sprintf("%d", limit)).(UpdateBuilder)
+	b.data.Limit = fmt.Sprintf("%d", limit)
+	return b
 }
 
 // Offset sets a OFFSET clause on the query.
 func (b UpdateBuilder) Offset(offset uint64) UpdateBuilder {
-	return builder.Set(b, "Offset", fmt.Sprintf("%d", offset)).(UpdateBuilder)
+	b.data.Offset = fmt.Sprintf("%d", offset)
+	return b
+}
+
+// Returning adds a RETURNING <columns> clause (before the [UpdateBuilder.Suffix])
+// to the query, or an OUTPUT clause for dialects that use one instead.
+func (b UpdateBuilder) Returning(columns ...string) UpdateBuilder {
+	b.data.Returning = appendCopy(b.data.Returning, columns...)
+	return b
 }
 
 // Suffix adds an expression to the end of the query
@@ -253,5 +437,6 @@ func (b UpdateBuilder) Suffix(sql string, args ...interface{}) UpdateBuilder {
 
 // SuffixExpr adds an expression to the end of the query
 func (b UpdateBuilder) SuffixExpr(expr Sqlizer) UpdateBuilder {
-	return builder.Append(b, "Suffixes", expr).(UpdateBuilder)
+	b.data.Suffixes = appendCopy(b.data.Suffixes, expr)
+	return b
 }
@@ -118,3 +118,54 @@ func TestUpdateSetStruct(t *testing.T) {
 	}
 	assert.Equal(t, expectedArgs, args)
 }
+
+func TestUpdateBuilderWhereStruct(t *testing.T) {
+	filter := struct {
+		Status string `sq:"status"`
+		Before int    `sq:"created_at,op=lt"`
+	}{Status: "pending", Before: 100}
+
+	sql, args, err := Update("jobs").Set("status", "expired").WhereStruct(filter).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE jobs SET status = ? WHERE (status = ? AND created_at < ?)", sql)
+	assert.Equal(t, []interface{}{"expired", "pending", 100}, args)
+
+	sql, _, err = Update("jobs").Set("status", "expired").WhereStruct(struct {
+		Status string `sq:"status"`
+	}{}).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE jobs SET status = ?", sql)
+}
+
+func BenchmarkUpdateBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Update("t").
+			Set("a", i).
+			Set("b", "b").
+			Where(Eq{"id": 1}).
+			ToSql()
+	}
+}
+
+func TestUpdateBuilderFromJoins(t *testing.T) {
+	totals := Select("user_id", "sum(amount) AS total").From("payments").Where("status = ?", "paid").GroupBy("user_id")
+
+	sql, args, err := Update("users").
+		Set("balance", Expr("p.total")).
+		FromSelect(totals, "p").
+		From("plans").
+		JoinSelect(Select("id").From("regions").Where("active = ?", true), "r", Expr("r.id = plans.region_id")).
+		Where("users.id = p.user_id AND plans.id = users.plan_id").
+		Where("users.balance <> ?", 0).
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "UPDATE users SET balance = p.total " +
+		"FROM (SELECT user_id, sum(amount) AS total FROM payments WHERE status = $1 GROUP BY user_id) AS p, plans " +
+		"JOIN (SELECT id FROM regions WHERE active = $2) AS r ON r.id = plans.region_id " +
+		"WHERE users.id = p.user_id AND plans.id = users.plan_id AND users.balance <> $3"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{"paid", true, 0}, args)
+}
@@ -2,6 +2,7 @@ package squirrelly
 
 import (
 	"fmt"
+	"reflect"
 )
 
 type wherePart part
@@ -10,16 +11,18 @@ func newWherePart(pred interface{}, args ...interface{}) Sqlizer {
 	return &wherePart{pred: pred, args: args}
 }
 
-func (p wherePart) ToSql() (sql string, args []interface{}, err error) {
+func (p wherePart) ToSql() (string, []interface{}, error) {
+	return p.toSqlDialect(nil)
+}
+
+func (p wherePart) toSqlDialect(d Dialect) (sql string, args []interface{}, err error) {
 	switch pred := p.pred.(type) {
 	case nil:
 		// no-op
-	case rawSqlizer:
-		return pred.toSqlRaw()
 	case Sqlizer:
-		return pred.ToSql()
+		return nestedToSql(pred, d)
 	case map[string]interface{}:
-		return Eq(pred).ToSql()
+		return Eq(pred).toSQL(false, d)
 	case string:
 		sql = pred
 		args = p.args
@@ -28,3 +31,61 @@ func (p wherePart) ToSql() (sql string, args []interface{}, err error) {
 	}
 	return
 }
+
+// structFilterOps maps the `op` option of a struct filter's tag to the
+// predicate type used to render the field.
+var structFilterOps = map[string]func(column string, value interface{}) Sqlizer{
+	"":         func(c string, v interface{}) Sqlizer { return Eq{c: v} },
+	"eq":       func(c string, v interface{}) Sqlizer { return Eq{c: v} },
+	"ne":       func(c string, v interface{}) Sqlizer { return NotEq{c: v} },
+	"neq":      func(c string, v interface{}) Sqlizer { return NotEq{c: v} },
+	"lt":       func(c string, v interface{}) Sqlizer { return Lt{c: v} },
+	"lte":      func(c string, v interface{}) Sqlizer { return LtOrEq{c: v} },
+	"gt":       func(c string, v interface{}) Sqlizer { return Gt{c: v} },
+	"gte":      func(c string, v interface{}) Sqlizer { return GtOrEq{c: v} },
+	"like":     func(c string, v interface{}) Sqlizer { return Like{c: v} },
+	"notlike":  func(c string, v interface{}) Sqlizer { return NotLike{c: v} },
+	"ilike":    func(c string, v interface{}) Sqlizer { return ILike{c: v} },
+	"notilike": func(c string, v interface{}) Sqlizer { return NotILike{c: v} },
+}
+
+// newStructWherePart builds a predicate from the tagged fields of a filter
+// struct, see SelectBuilder.WhereStruct. It returns nil if no fields are set.
+func newStructWherePart(mapper *Mapper, filter interface{}) (Sqlizer, error) {
+	value := reflect.Indirect(reflect.ValueOf(filter))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct filter, not %T", filter)
+	}
+
+	typeMap := mapper.typeMap(value.Type())
+
+	preds := And{}
+	for _, field := range columnFields(typeMap) {
+		opName := field.Options["op"]
+		op, ok := structFilterOps[opName]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter op `%s` on column `%s`", opName, field.Path)
+		}
+
+		fieldValue := fieldByIndexesNilable(value, field.Index)
+		if !fieldValue.IsValid() || fieldValue.IsZero() {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue = fieldValue.Elem()
+		}
+		// empty slices and maps are usually a filter that wasn't given, rather than
+		// one that should match nothing
+		if k := fieldValue.Kind(); (k == reflect.Slice || k == reflect.Map) && fieldValue.Len() == 0 {
+			continue
+		}
+
+		preds = append(preds, op(field.Path, fieldValue.Interface()))
+	}
+
+	if len(preds) == 0 {
+		return nil, nil
+	}
+
+	return preds, nil
+}
@@ -2,6 +2,7 @@ package squirrelly
 
 import (
 	"testing"
+	"time"
 
 	"bytes"
 
@@ -15,14 +16,14 @@ func TestWherePartsAppendToSql(t *testing.T) {
 		newWherePart(Eq{"y": 2}),
 	}
 	sql := &bytes.Buffer{}
-	args, _ := appendToSql(parts, sql, " AND ", []interface{}{})
+	args, _ := appendToSql(parts, nil, sql, " AND ", []interface{}{})
 	assert.Equal(t, "x = ? AND y = ?", sql.String())
 	assert.Equal(t, []interface{}{1, 2}, args)
 }
 
 func TestWherePartsAppendToSqlErr(t *testing.T) {
 	parts := []Sqlizer{newWherePart(1)}
-	_, err := appendToSql(parts, &bytes.Buffer{}, "", []interface{}{})
+	_, err := appendToSql(parts, nil, &bytes.Buffer{}, "", []interface{}{})
 	assert.Error(t, err)
 }
 
@@ -54,3 +55,85 @@ func TestWherePartMap(t *testing.T) {
 	test(m)
 	test(Eq(m))
 }
+
+type whereStructAddress struct {
+	City string `sq:"city"`
+}
+
+type whereStructFilter struct {
+	Status    string             `sq:"status"`
+	Name      string             `sq:"name,op=like"`
+	CreatedAt *time.Time         `sq:"created_at,op=gte"`
+	Before    *time.Time         `sq:"created_at_before,op=lt"`
+	Archived  *bool              `sq:"archived"`
+	Ids       []int              `sq:"id"`
+	Address   whereStructAddress `sq:"address"`
+	Skipped   string             `sq:"-"`
+}
+
+func TestStructWherePart(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	archived := false
+
+	filter := whereStructFilter{
+		Status:    "active",
+		Name:      "%irrel%",
+		CreatedAt: &createdAt,
+		Archived:  &archived,
+		Ids:       []int{1, 2},
+		Address:   whereStructAddress{City: "Portland"},
+		Skipped:   "skipped",
+	}
+
+	pred, err := newStructWherePart(getMapper(), &filter)
+	assert.NoError(t, err)
+
+	expected := And{
+		Eq{"status": "active"},
+		Like{"name": "%irrel%"},
+		GtOrEq{"created_at": createdAt},
+		Eq{"archived": false},
+		Eq{"id": []int{1, 2}},
+		Eq{"address.city": "Portland"},
+	}
+	assert.Equal(t, expected, pred)
+
+	for i := 0; i < 10; i++ {
+		sql, args, err := pred.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, "(status = ? AND name LIKE ? AND created_at >= ? AND archived = ? AND id IN (?,?) AND address.city = ?)", sql)
+		assert.Equal(t, []interface{}{"active", "%irrel%", createdAt, false, 1, 2, "Portland"}, args)
+	}
+}
+
+func TestStructWherePartEmpty(t *testing.T) {
+	pred, err := newStructWherePart(getMapper(), whereStructFilter{})
+	assert.NoError(t, err)
+	assert.Nil(t, pred)
+}
+
+func TestStructWherePartEmptySlices(t *testing.T) {
+	ids := []int{}
+	pred, err := newStructWherePart(getMapper(), struct {
+		Status string         `sq:"status"`
+		Ids    []int          `sq:"id"`
+		IdPtr  *[]int         `sq:"id_ptr"`
+		Tags   map[string]int `sq:"tags"`
+	}{Status: "active", Ids: ids, IdPtr: &ids, Tags: map[string]int{}})
+	assert.NoError(t, err)
+	assert.Equal(t, And{Eq{"status": "active"}}, pred)
+
+	pred, err = newStructWherePart(getMapper(), whereStructFilter{Ids: []int{}})
+	assert.NoError(t, err)
+	assert.Nil(t, pred)
+}
+
+func TestStructWherePartErr(t *testing.T) {
+	_, err := newStructWherePart(getMapper(), 1)
+	assert.Error(t, err)
+
+	_, err = newStructWherePart(getMapper(), struct {
+		Name string `sq:"name,op=regex"`
+	}{Name: "foo"})
+	assert.EqualError(t, err, "unknown filter op `regex` on column `name`")
+}
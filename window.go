@@ -0,0 +1,185 @@
+package squirrelly
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Window frame bounds, for use with WindowBuilder.Rows, Range and Groups.
+const (
+	UnboundedPreceding = "UNBOUNDED PRECEDING"
+	CurrentRow         = "CURRENT ROW"
+	UnboundedFollowing = "UNBOUNDED FOLLOWING"
+)
+
+// Preceding returns the window frame bound n rows (or values, or groups) before the current row.
+func Preceding(n uint64) string {
+	return fmt.Sprintf("%d PRECEDING", n)
+}
+
+// Following returns the window frame bound n rows (or values, or groups) after the current row.
+func Following(n uint64) string {
+	return fmt.Sprintf("%d FOLLOWING", n)
+}
+
+type windowData struct {
+	Function     Sqlizer
+	Base         string
+	PartitionBys []string
+	OrderBys     []string
+	Frame        string
+}
+
+// ToSql renders the window function followed by its OVER clause.
+func (d *windowData) ToSql() (string, []interface{}, error) {
+	return d.toSqlDialect(nil)
+}
+
+func (d *windowData) toSqlDialect(dialect Dialect) (sqlStr string, args []interface{}, err error) {
+	if d.Function == nil {
+		err = errors.New("window expressions must have a function")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	args, err = appendToSql([]Sqlizer{d.Function}, dialect, sql, "", args)
+	if err != nil {
+		return
+	}
+
+	sql.WriteString(" OVER ")
+
+	// a named window that isn't refined can be referenced without parentheses
+	if d.Base != "" && len(d.PartitionBys) == 0 && len(d.OrderBys) == 0 && d.Frame == "" {
+		sql.WriteString(d.Base)
+	} else {
+		sql.WriteString("(")
+		sql.WriteString(d.specSql())
+		sql.WriteString(")")
+	}
+
+	sqlStr = sql.String()
+	return
+}
+
+// specSql renders the window specification, without its surrounding parentheses.
+func (d *windowData) specSql() string {
+	parts := []string{}
+
+	if d.Base != "" {
+		parts = append(parts, d.Base)
+	}
+
+	if len(d.PartitionBys) > 0 {
+		parts = append(parts, "PARTITION BY "+strings.Join(d.PartitionBys, ", "))
+	}
+
+	if len(d.OrderBys) > 0 {
+		parts = append(parts, "ORDER BY "+strings.Join(d.OrderBys, ", "))
+	}
+
+	if d.Frame != "" {
+		parts = append(parts, d.Frame)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Builder
+
+// WindowBuilder builds window function expressions, with an OVER clause
+// describing the window, for use as a column of a SelectBuilder:
+//
+//	Select("id").
+//		Column(Alias(Window("row_number()").PartitionBy("dept").OrderBy("salary DESC"), "rank")).
+//		Column(Window("sum(amount)").OrderBy("created_at").Rows(UnboundedPreceding, CurrentRow)).
+//		From("employees")
+//
+// Windows without a function, created with WindowSpec, define the named
+// windows of SelectBuilder.Window.
+type WindowBuilder struct {
+	data windowData
+}
+
+// Window returns a WindowBuilder for the window function, which is a string
+// with args or a Sqlizer.
+func Window(function interface{}, args ...interface{}) WindowBuilder {
+	return WindowBuilder{data: windowData{Function: newPart(function, args...)}}
+}
+
+// WindowSpec returns a WindowBuilder without a function, for use with
+// SelectBuilder.Window.
+func WindowSpec() WindowBuilder {
+	return WindowBuilder{}
+}
+
+// ToSql builds the window function into a SQL string and bound args.
+func (b WindowBuilder) ToSql() (string, []interface{}, error) {
+	return b.data.ToSql()
+}
+
+func (b WindowBuilder) toSqlDialect(d Dialect) (string, []interface{}, error) {
+	return b.data.toSqlDialect(d)
+}
+
+// Over bases the window on a named window, defined with SelectBuilder.Window.
+// Without further partitioning, ordering or frame clauses, this renders
+// OVER name.
+func (b WindowBuilder) Over(name string) WindowBuilder {
+	b.data.Base = name
+	return b
+}
+
+// PartitionBy adds PARTITION BY expressions to the window.
+func (b WindowBuilder) PartitionBy(partitionBys ...string) WindowBuilder {
+	b.data.PartitionBys = appendCopy(b.data.PartitionBys, partitionBys...)
+	return b
+}
+
+// OrderBy adds ORDER BY expressions to the window.
+func (b WindowBuilder) OrderBy(orderBys ...string) WindowBuilder {
+	b.data.OrderBys = appendCopy(b.data.OrderBys, orderBys...)
+	return b
+}
+
+// Rows sets a ROWS frame on the window. If end is empty, the frame is rendered
+// as ROWS start, otherwise as ROWS BETWEEN start AND end.
+func (b WindowBuilder) Rows(start, end string) WindowBuilder {
+	return b.frame("ROWS", start, end)
+}
+
+// Range sets a RANGE frame on the window.
+//
+// See WindowBuilder.Rows for more information.
+func (b WindowBuilder) Range(start, end string) WindowBuilder {
+	return b.frame("RANGE", start, end)
+}
+
+// Groups sets a GROUPS frame on the window.
+//
+// See WindowBuilder.Rows for more information.
+func (b WindowBuilder) Groups(start, end string) WindowBuilder {
+	return b.frame("GROUPS", start, end)
+}
+
+func (b WindowBuilder) frame(unit, start, end string) WindowBuilder {
+	if end == "" {
+		b.data.Frame = fmt.Sprintf("%s %s", unit, start)
+	} else {
+		b.data.Frame = fmt.Sprintf("%s BETWEEN %s AND %s", unit, start, end)
+	}
+	return b
+}
+
+// namedWindow is a window defined in the WINDOW clause of a select statement.
+type namedWindow struct {
+	name string
+	spec WindowBuilder
+}
+
+func (w namedWindow) ToSql() (string, []interface{}, error) {
+	return fmt.Sprintf("%s AS (%s)", w.name, w.spec.data.specSql()), nil, nil
+}
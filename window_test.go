@@ -0,0 +1,94 @@
+package squirrelly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowBuilderToSql(t *testing.T) {
+	type testCase struct {
+		window       WindowBuilder
+		expectedSql  string
+		expectedArgs []interface{}
+	}
+
+	cases := []testCase{
+		{
+			window:      Window("row_number()"),
+			expectedSql: "row_number() OVER ()",
+		},
+		{
+			window:      Window("row_number()").PartitionBy("dept", "team").OrderBy("salary DESC", "id"),
+			expectedSql: "row_number() OVER (PARTITION BY dept, team ORDER BY salary DESC, id)",
+		},
+		{
+			window:      Window("sum(amount)").OrderBy("created_at").Rows(UnboundedPreceding, CurrentRow),
+			expectedSql: "sum(amount) OVER (ORDER BY created_at ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)",
+		},
+		{
+			window:      Window("avg(price)").OrderBy("day").Range(Preceding(7), Following(7)),
+			expectedSql: "avg(price) OVER (ORDER BY day RANGE BETWEEN 7 PRECEDING AND 7 FOLLOWING)",
+		},
+		{
+			window:      Window("count(*)").OrderBy("score").Groups(CurrentRow, ""),
+			expectedSql: "count(*) OVER (ORDER BY score GROUPS CURRENT ROW)",
+		},
+		{
+			window:      Window("rank()").Over("w"),
+			expectedSql: "rank() OVER w",
+		},
+		{
+			window:      Window("sum(x)").Over("w").Rows(Preceding(1), UnboundedFollowing),
+			expectedSql: "sum(x) OVER (w ROWS BETWEEN 1 PRECEDING AND UNBOUNDED FOLLOWING)",
+		},
+		{
+			window:       Window("lag(x, ?)", 0).OrderBy("id"),
+			expectedSql:  "lag(x, ?) OVER (ORDER BY id)",
+			expectedArgs: []interface{}{0},
+		},
+		{
+			window:       Window(Expr("nth_value(x, ?)", 2)).PartitionBy("g"),
+			expectedSql:  "nth_value(x, ?) OVER (PARTITION BY g)",
+			expectedArgs: []interface{}{2},
+		},
+	}
+
+	for _, c := range cases {
+		sql, args, err := c.window.ToSql()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expectedSql, sql)
+		assert.Equal(t, c.expectedArgs, args)
+	}
+
+	_, _, err := WindowSpec().PartitionBy("dept").ToSql()
+	assert.Error(t, err)
+}
+
+func TestSelectBuilderWindow(t *testing.T) {
+	sql, args, err := Select("dept", "id").
+		Column(Alias(Window("row_number()").Over("w"), "rn")).
+		Column(Window("sum(salary)").Over("w").Rows(UnboundedPreceding, CurrentRow)).
+		Column(Window("lag(salary, ?)", 1).Over("by_hire")).
+		From("employees").
+		Where("active = ?", true).
+		GroupBy("dept", "id", "salary", "hired_at").
+		Having("count(*) > ?", 0).
+		Window("w", WindowSpec().PartitionBy("dept").OrderBy("salary DESC")).
+		Window("by_hire", WindowSpec().OrderBy("hired_at")).
+		OrderBy("dept").
+		PlaceholderFormat(Dollar).
+		ToSql()
+	assert.NoError(t, err)
+
+	expectedSql := "SELECT dept, id, " +
+		"(row_number() OVER w) AS rn, " +
+		"sum(salary) OVER (w ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW), " +
+		"lag(salary, $1) OVER by_hire " +
+		"FROM employees WHERE active = $2 " +
+		"GROUP BY dept, id, salary, hired_at HAVING count(*) > $3 " +
+		"WINDOW w AS (PARTITION BY dept ORDER BY salary DESC), by_hire AS (ORDER BY hired_at) " +
+		"ORDER BY dept"
+	assert.Equal(t, expectedSql, sql)
+	assert.Equal(t, []interface{}{1, true, 0}, args)
+}